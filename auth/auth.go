@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"time"
+)
+
+const NonceSize = 32
+
+// Token layout: node Id (4 bytes), issue time in unix nanoseconds (8 bytes), HMAC-SHA256 over both (32 bytes)
+const TokenSize = 4 + 8 + sha256.Size
+
+// Reads a pre-shared key from a file, ignoring surrounding whitespace
+func LoadKey(file string) ([]byte, error) {
+	key, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading pre-shared key: %w", err)
+	}
+
+	key = bytes.TrimSpace(key)
+	if len(key) < 16 {
+		return nil, fmt.Errorf("pre-shared key in %s is too short, use at least 16 bytes", file)
+	}
+	return key, nil
+}
+
+func NewNonce() ([]byte, error) {
+	nonce := make([]byte, NonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return nonce, nil
+}
+
+// Random key for signing session tokens when no pre-shared key is configured
+func NewKey() ([]byte, error) {
+	key := make([]byte, sha256.Size)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Proves knowledge of the key for a registration challenge.
+// The address is covered as well, so the answer can't be replayed for another address.
+func ChallengeResponse(key []byte, nonce []byte, address string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("challenge"))
+	mac.Write(nonce)
+	mac.Write([]byte(address))
+	return mac.Sum(nil)
+}
+
+func ChallengeResponseIsValid(key []byte, nonce []byte, address string, response []byte) bool {
+	return hmac.Equal(ChallengeResponse(key, nonce, address), response)
+}
+
+// Issues the session token a node has to present with its control messages
+func IssueToken(key []byte, id int32) []byte {
+	token := make([]byte, 12, TokenSize)
+	binary.BigEndian.PutUint32(token[0:4], uint32(id))
+	binary.BigEndian.PutUint64(token[4:12], uint64(time.Now().UnixNano()))
+	return append(token, tokenMac(key, token)...)
+}
+
+// Checks that a token was issued by this registry, to the node with the given Id
+func VerifyToken(key []byte, token []byte, id int32) error {
+	tokenId, err := ParseToken(key, token)
+	if err != nil {
+		return err
+	}
+	if tokenId != id {
+		return fmt.Errorf("session token belongs to node %d, not node %d", tokenId, id)
+	}
+	return nil
+}
+
+// Checks that a token was issued by this registry, and returns the Id of the node it was issued to
+func ParseToken(key []byte, token []byte) (int32, error) {
+	if len(token) != TokenSize {
+		return -1, fmt.Errorf("missing or malformed session token")
+	}
+	if !hmac.Equal(tokenMac(key, token[:12]), token[12:]) {
+		return -1, fmt.Errorf("session token has an invalid signature")
+	}
+	return int32(binary.BigEndian.Uint32(token[0:4])), nil
+}
+
+func tokenMac(key []byte, claims []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("token"))
+	mac.Write(claims)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadKey(t *testing.T) {
+	tests := []struct {
+		content string
+		key     string
+		fails   bool
+	}{
+		{content: "0123456789abcdef", key: "0123456789abcdef"},
+		{content: "  0123456789abcdef\n", key: "0123456789abcdef"},
+		{content: "0123456789abcde\n", fails: true},
+		{content: "", fails: true},
+	}
+
+	dir := t.TempDir()
+	for i, test := range tests {
+		path := filepath.Join(dir, "psk")
+		if err := os.WriteFile(path, []byte(test.content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		key, err := LoadKey(path)
+		if test.fails {
+			if err == nil {
+				t.Errorf("case %d: LoadKey(%q) = %q, want an error", i, test.content, key)
+			}
+			continue
+		}
+		if err != nil || string(key) != test.key {
+			t.Errorf("case %d: LoadKey(%q) = %q, %v, want %q", i, test.content, key, err, test.key)
+		}
+	}
+
+	if _, err := LoadKey(filepath.Join(dir, "missing")); err == nil {
+		t.Error("LoadKey of a missing file succeeded")
+	}
+}
+
+func TestChallengeResponseIsValid(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	nonce := bytes.Repeat([]byte{1}, NonceSize)
+	response := ChallengeResponse(key, nonce, "127.0.0.1:5000")
+
+	tests := []struct {
+		name     string
+		key      []byte
+		nonce    []byte
+		address  string
+		response []byte
+		valid    bool
+	}{
+		{name: "matching", key: key, nonce: nonce, address: "127.0.0.1:5000", response: response, valid: true},
+		{name: "other key", key: []byte("fedcba9876543210"), nonce: nonce, address: "127.0.0.1:5000", response: response},
+		{name: "other nonce", key: key, nonce: bytes.Repeat([]byte{2}, NonceSize), address: "127.0.0.1:5000", response: response},
+		{name: "other address", key: key, nonce: nonce, address: "127.0.0.1:5001", response: response},
+		{name: "truncated", key: key, nonce: nonce, address: "127.0.0.1:5000", response: response[:16]},
+		{name: "missing", key: key, nonce: nonce, address: "127.0.0.1:5000"},
+	}
+
+	for _, test := range tests {
+		if valid := ChallengeResponseIsValid(test.key, test.nonce, test.address, test.response); valid != test.valid {
+			t.Errorf("%s: ChallengeResponseIsValid = %v, want %v", test.name, valid, test.valid)
+		}
+	}
+}
+
+func TestVerifyToken(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	token := IssueToken(key, 42)
+
+	tampered := bytes.Clone(token)
+	tampered[3] = 43
+
+	tests := []struct {
+		name  string
+		key   []byte
+		token []byte
+		id    int32
+		valid bool
+	}{
+		{name: "issued", key: key, token: token, id: 42, valid: true},
+		{name: "other node", key: key, token: token, id: 43},
+		{name: "other key", key: []byte("fedcba9876543210"), token: token, id: 42},
+		{name: "tampered id", key: key, token: tampered, id: 43},
+		{name: "truncated", key: key, token: token[:TokenSize-1], id: 42},
+		{name: "missing", key: key, token: nil, id: 42},
+	}
+
+	for _, test := range tests {
+		if err := VerifyToken(test.key, test.token, test.id); (err == nil) != test.valid {
+			t.Errorf("%s: VerifyToken = %v, want valid %v", test.name, err, test.valid)
+		}
+	}
+}
+
+func TestParseToken(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	for _, id := range []int32{0, 1, 127} {
+		token := IssueToken(key, id)
+		if len(token) != TokenSize {
+			t.Errorf("IssueToken(%d) is %d bytes, want %d", id, len(token), TokenSize)
+		}
+		if parsed, err := ParseToken(key, token); err != nil || parsed != id {
+			t.Errorf("ParseToken(IssueToken(%d)) = %d, %v", id, parsed, err)
+		}
+	}
+}
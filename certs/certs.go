@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/lsig/OverlayNetwork/cluster"
+	"github.com/lsig/OverlayNetwork/logger"
+	"github.com/lsig/OverlayNetwork/transport"
+)
+
+// Generates a local CA, a certificate for the registry and one certificate per messaging node
+func main() {
+	dir := flag.String("dir", "tls", "directory the certificates and keys are written to")
+	nodes := flag.Int("nodes", 10, "number of node certificates to generate")
+	hosts := flag.String("hosts", "127.0.0.1,::1,localhost", "comma separated hosts the certificates are valid for")
+	loopback := flag.Bool("loopback", false, "also make the certificate of node-<i> valid for the loopback address a cluster started with -loopback gives it")
+	flag.Parse()
+
+	hostList := strings.Split(*hosts, ",")
+
+	// keep an existing CA, so certificates for additional nodes can be generated later on
+	if _, err := os.Stat(filepath.Join(*dir, "ca.pem")); err == nil {
+		logger.Infof("Reusing CA in %s", *dir)
+	} else {
+		if err := transport.GenerateCA(*dir); err != nil {
+			logger.Errorf("error generating CA: %s", err.Error())
+			os.Exit(1)
+		}
+		logger.Infof("Generated CA in %s", *dir)
+	}
+
+	names := []string{"registry"}
+	for i := 1; i <= *nodes; i++ {
+		names = append(names, fmt.Sprintf("node-%d", i))
+	}
+
+	for i, name := range names {
+		nameHosts := hostList
+		if *loopback && i > 0 {
+			nameHosts = append(slices.Clone(hostList), cluster.LoopbackAddress(i).String())
+		}
+		if err := transport.GenerateCertificate(*dir, name, nameHosts); err != nil {
+			logger.Errorf("error generating certificate for %s: %s", name, err.Error())
+			os.Exit(1)
+		}
+	}
+	logger.Infof("Generated certificates for %s, valid for %s", strings.Join(names, ", "), strings.Join(hostList, ", "))
+	if *loopback {
+		logger.Infof("The certificate of node-<i> is also valid for its loopback address, %s for node-1", cluster.LoopbackAddress(1))
+	}
+}
@@ -0,0 +1,279 @@
+package cluster
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/lsig/OverlayNetwork/logger"
+	reg "github.com/lsig/OverlayNetwork/registry/registry"
+	"github.com/lsig/OverlayNetwork/transport"
+)
+
+// How long the registry has to accept connections before the nodes are started
+const RegistryStartTimeout = 10 * time.Second
+
+// How long processes have to exit on their own once the registry has, before they're interrupted.
+// Nodes the registry didn't shut down keep trying to resume their session for longer.
+const ExitGrace = 1 * time.Second
+
+// How long interrupted processes have to exit, before they're killed
+const StopTimeout = 5 * time.Second
+
+// The address node i listens on and connects from with -loopback, 127.0.0.1 being the registry's.
+// Linux routes all of 127.0.0.0/8 to the loopback interface, other systems may need the addresses added to it first.
+func LoopbackAddress(i int) net.IP {
+	n := uint32(127<<24 + 1 + i)
+	return net.IPv4(byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
+
+// Nodes that can have their own loopback address, 127.0.0.2 to 127.255.255.254
+const MaxLoopbackNodes = 1<<24 - 3
+
+// A subprocess of the cluster, and where its output goes
+type Process struct {
+	Name  string
+	Cmd   *exec.Cmd
+	Log   *os.File
+	Stdin io.WriteCloser // kept open for nodes, which stop reading commands once it closes
+	Done  chan struct{}  // closed once the process has exited
+}
+
+func start(name string, executable string, args []string, logDir string, stdout io.Writer) (*Process, error) {
+	log, err := os.Create(filepath.Join(logDir, name+".log"))
+	if err != nil {
+		return nil, err
+	}
+
+	process := &Process{Name: name, Cmd: exec.Command(executable, args...), Log: log, Done: make(chan struct{})}
+	if stdout != nil {
+		process.Cmd.Stdout = io.MultiWriter(stdout, log)
+		process.Cmd.Stdin = os.Stdin
+	} else {
+		process.Cmd.Stdout = log
+		if process.Stdin, err = process.Cmd.StdinPipe(); err != nil {
+			log.Close()
+			return nil, err
+		}
+	}
+	process.Cmd.Stderr = process.Cmd.Stdout
+
+	if err := process.Cmd.Start(); err != nil {
+		log.Close()
+		return nil, err
+	}
+	go func() {
+		process.Cmd.Wait()
+		if process.Stdin != nil {
+			process.Stdin.Close()
+		}
+		process.Log.Close()
+		close(process.Done)
+	}()
+	return process, nil
+}
+
+// Waits for the process to exit, interrupting it after ExitGrace, and killing it after StopTimeout more
+func (p *Process) stop() {
+	select {
+	case <-p.Done:
+		return
+	case <-time.After(ExitGrace):
+	}
+
+	p.Cmd.Process.Signal(os.Interrupt)
+	select {
+	case <-p.Done:
+		return
+	case <-time.After(StopTimeout):
+	}
+
+	logger.Warningf("%s did not exit when interrupted, killing it", p.Name)
+	p.Cmd.Process.Kill()
+	<-p.Done
+}
+
+// Starts a registry and n nodes on this machine as subprocesses of the running binary, writing their output to one log file each.
+// The registry reads the cluster's stdin and prints to its stdout as well. Once the registry exits, so does the cluster, after its nodes.
+func Main(args []string) {
+	flags := flag.NewFlagSet("cluster", flag.ExitOnError)
+	n := flags.Int("n", 10, "number of nodes to start")
+	logDir := flags.String("logs", "cluster-logs", "directory the log files of the registry and the nodes are written to")
+	transportName := flags.String("transport", "tcp", "transport used by the registry and the nodes: tcp or unix")
+	tlsDir := flags.String("tls", "", "directory of certificates generated by certs/certs.go for at least n nodes, to use mutual tls")
+	pskFile := flags.String("psk", "", "file holding a pre-shared key the nodes must prove knowledge of to register")
+	sign := flags.Bool("sign", false, "have the nodes sign their packets")
+	loopback := flags.Bool("loopback", false, "give every node its own address on the loopback network, 127.0.0.2 for node-1 and so on, to simulate nodes on different hosts")
+	seed := flags.Uint64("seed", 0, "seed of the registry, node i is seeded with seed+i, 0 leaves them random")
+	registryArgs := flags.String("registry-args", "", "further arguments for the registry, e.g. \"-dataplane udp -script run.txt\"")
+	nodeArgs := flags.String("node-args", "", "further arguments for every node")
+	flags.Parse(args)
+
+	if *n < 1 {
+		logger.Error("The cluster needs at least one node")
+		os.Exit(1)
+	}
+	if *loopback && *transportName != "tcp" {
+		logger.Error("Nodes can only have their own loopback address with the tcp transport, the others report every peer as 127.0.0.1")
+		os.Exit(1)
+	}
+	if *loopback && *n > MaxLoopbackNodes {
+		logger.Errorf("The loopback network has addresses for %d nodes", MaxLoopbackNodes)
+		os.Exit(1)
+	}
+	if *transportName == "pipe" {
+		logger.Error("The pipe transport only connects programs in the same process, use tcp or unix")
+		os.Exit(1)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		logger.Errorf("Failed to find the overlay binary: %v", err)
+		os.Exit(1)
+	}
+	if err := os.MkdirAll(*logDir, 0o755); err != nil {
+		logger.Errorf("Failed to create the log directory: %v", err)
+		os.Exit(1)
+	}
+
+	common := []string{"-transport", *transportName}
+	if *pskFile != "" {
+		common = append(common, "-psk", *pskFile)
+	}
+	tlsArgs := func(name string) []string {
+		if *tlsDir == "" {
+			return nil
+		}
+		return []string{
+			"-tls-ca", filepath.Join(*tlsDir, "ca.pem"),
+			"-tls-cert", filepath.Join(*tlsDir, name+".pem"),
+			"-tls-key", filepath.Join(*tlsDir, name+"-key.pem"),
+		}
+	}
+
+	// interrupts are for the cluster to handle, it passes them on to its processes itself
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	args = append([]string{"registry"}, common...)
+	args = append(args, tlsArgs("registry")...)
+	if *seed != 0 {
+		args = append(args, "-seed", strconv.FormatUint(*seed, 10))
+	}
+	args = append(args, strings.Fields(*registryArgs)...)
+	registryAddress, err := RegistryAddress(args[1:])
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	registry, err := start("registry", executable, args, *logDir, os.Stdout)
+	if err != nil {
+		logger.Errorf("Failed to start the registry: %v", err)
+		os.Exit(1)
+	}
+
+	nodes := []*Process{}
+	stop := func() int {
+		registry.stop()
+		for _, node := range nodes {
+			node.stop()
+		}
+		status := registry.Cmd.ProcessState.ExitCode()
+		if status < 0 {
+			// killed by a signal
+			status = 1
+		}
+		logger.Infof("Cluster stopped, logs are in %s", *logDir)
+		return status
+	}
+
+	if err := waitForRegistry(*transportName, registryAddress, registry); err != nil {
+		logger.Error(err.Error())
+		os.Exit(stop())
+	}
+
+	for i := 1; i <= *n; i++ {
+		name := fmt.Sprintf("node-%d", i)
+		args := append([]string{"node"}, common...)
+		args = append(args, tlsArgs(name)...)
+		if *sign {
+			args = append(args, "-sign")
+		}
+		if *seed != 0 {
+			args = append(args, "-seed", strconv.FormatUint(*seed+uint64(i), 10))
+		}
+		if *loopback {
+			address := LoopbackAddress(i).String()
+			args = append(args, "-host", address, "-advertise", address)
+		}
+		args = append(args, strings.Fields(*nodeArgs)...)
+		args = append(args, registryAddress)
+
+		node, err := start(name, executable, args, *logDir, nil)
+		if err != nil {
+			logger.Errorf("Failed to start %s: %v", name, err)
+			os.Exit(stop())
+		}
+		nodes = append(nodes, node)
+	}
+	logger.Infof("Started a registry and %d nodes, logs are in %s", *n, *logDir)
+
+	select {
+	case <-registry.Done:
+	case <-signals:
+		logger.Info("Interrupted, stopping the cluster")
+		registry.Cmd.Process.Signal(os.Interrupt)
+	}
+	os.Exit(stop())
+}
+
+// Where the nodes find a registry started with args, the -host and -port it listens on after its config file is read.
+// A registry listening on every address is found on the loopback one.
+func RegistryAddress(args []string) (string, error) {
+	cfg, err := reg.ParseConfig(args)
+	if err != nil {
+		return "", err
+	}
+	if cfg.Port == 0 {
+		return "", fmt.Errorf("The registry needs a -port other than 0 in a cluster, for the nodes to find it")
+	}
+
+	host := cfg.Host
+	if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, strconv.Itoa(cfg.Port)), nil
+}
+
+// Waits until the registry at address accepts connections, or exits
+func waitForRegistry(transportName string, address string, registry *Process) error {
+	// the nodes only need the listener to be up, so tls can be left out
+	tr, err := transport.Setup(transportName, "", "", "")
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(RegistryStartTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-registry.Done:
+			return fmt.Errorf("The registry exited before the nodes were started")
+		default:
+		}
+		if conn, err := tr.Dial(address); err == nil {
+			conn.Close()
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return fmt.Errorf("The registry did not accept connections within %s", RegistryStartTimeout)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/lsig/OverlayNetwork/cluster"
+	"github.com/lsig/OverlayNetwork/messages/helpers"
+	"github.com/lsig/OverlayNetwork/registry/registry"
+)
+
+const usage = `usage: overlay <command> [flags] [arguments]
+
+commands:
+  registry   run the registry
+  node       run a messaging node, given the registry as <host>:<port>
+  cluster    run a registry and -n nodes on this machine
+
+Run overlay <command> -h for the flags of a command.
+`
+
+// A single binary for the registry, a messaging node, or a whole overlay on this machine
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "registry":
+		registry.Main(os.Args[2:])
+	case "node":
+		helpers.Main(os.Args[2:])
+	case "cluster":
+		cluster.Main(os.Args[2:])
+	case "-h", "-help", "--help", "help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+// A duration written as a string such as "10s" in config files
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(content []byte) error {
+	var text string
+	if err := json.Unmarshal(content, &text); err != nil {
+		return fmt.Errorf("durations are strings such as \"10s\": %w", err)
+	}
+	duration, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+	d.Duration = duration
+	return nil
+}
+
+// Parses args into the flags, which must be bound to the fields of settings.
+// If a -config file is given, its json is read into settings first, and only the flags given in args override it.
+func Parse(flags *flag.FlagSet, args []string, settings any) error {
+	path := flags.String("config", "", "json file with the settings, flags that are given as well override it")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *path == "" {
+		return nil
+	}
+
+	given := map[string]string{}
+	flags.Visit(func(f *flag.Flag) {
+		given[f.Name] = f.Value.String()
+	})
+
+	content, err := os.ReadFile(*path)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(settings); err != nil {
+		return fmt.Errorf("error reading config %s: %w", *path, err)
+	}
+
+	for name, value := range given {
+		if err := flags.Set(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Prints the settings a program runs with, in the format of its config file
+func Print(name string, settings any) {
+	content, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return
+	}
+	fmt.Printf("%s settings:\n%s\n", name, content)
+}
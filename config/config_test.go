@@ -0,0 +1,76 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type settings struct {
+	Host    string   `json:"host"`
+	Port    int      `json:"port"`
+	Timeout Duration `json:"timeout"`
+}
+
+func TestParse(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	full := write("full.json", `{"host": "10.0.0.1", "port": 9000, "timeout": "30s"}`)
+	partial := write("partial.json", `{"port": 9000}`)
+	unknown := write("unknown.json", `{"hots": "10.0.0.1"}`)
+	badDuration := write("duration.json", `{"timeout": 30}`)
+	malformed := write("malformed.json", `{"port": `)
+
+	defaults := settings{Host: "localhost", Port: 8080, Timeout: Duration{10 * time.Second}}
+	tests := []struct {
+		name  string
+		args  []string
+		want  settings
+		fails bool
+	}{
+		{name: "defaults", args: nil, want: defaults},
+		{name: "flags", args: []string{"-port", "9001", "-timeout", "1m"}, want: settings{Host: "localhost", Port: 9001, Timeout: Duration{time.Minute}}},
+		{name: "config", args: []string{"-config", full}, want: settings{Host: "10.0.0.1", Port: 9000, Timeout: Duration{30 * time.Second}}},
+		{name: "config keeps defaults", args: []string{"-config", partial}, want: settings{Host: "localhost", Port: 9000, Timeout: Duration{10 * time.Second}}},
+		{name: "flags override config", args: []string{"-port", "9001", "-config", full}, want: settings{Host: "10.0.0.1", Port: 9001, Timeout: Duration{30 * time.Second}}},
+		{name: "flags after config override it", args: []string{"-config", full, "-host", "10.0.0.2"}, want: settings{Host: "10.0.0.2", Port: 9000, Timeout: Duration{30 * time.Second}}},
+		{name: "unknown setting", args: []string{"-config", unknown}, fails: true},
+		{name: "duration as number", args: []string{"-config", badDuration}, fails: true},
+		{name: "malformed", args: []string{"-config", malformed}, fails: true},
+		{name: "missing", args: []string{"-config", filepath.Join(dir, "missing.json")}, fails: true},
+		{name: "unknown flag", args: []string{"-hots", "10.0.0.1"}, fails: true},
+	}
+
+	for _, test := range tests {
+		cfg := defaults
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags.SetOutput(io.Discard)
+		flags.StringVar(&cfg.Host, "host", cfg.Host, "")
+		flags.IntVar(&cfg.Port, "port", cfg.Port, "")
+		flags.DurationVar(&cfg.Timeout.Duration, "timeout", cfg.Timeout.Duration, "")
+
+		err := Parse(flags, test.args, &cfg)
+		if test.fails {
+			if err == nil {
+				t.Errorf("%s: Parse(%v) = %+v, want an error", test.name, test.args, cfg)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Parse(%v) failed: %v", test.name, test.args, err)
+			continue
+		}
+		if cfg != test.want {
+			t.Errorf("%s: Parse(%v) = %+v, want %+v", test.name, test.args, cfg, test.want)
+		}
+	}
+}
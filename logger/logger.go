@@ -44,6 +44,18 @@ func SetLevel(level int) {
 	logger.Level = level
 }
 
+// Names of the levels, as given on the command line
+var LevelNames = []string{"debug", "info", "warning", "error"}
+
+func ParseLevel(name string) (int, error) {
+	for level, levelName := range LevelNames {
+		if name == levelName {
+			return level, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q, must be one of %s", name, strings.Join(LevelNames, ", "))
+}
+
 func Info(message string) {
 	message = strings.Trim(message, "\n")
 	if logger.Level <= InfoLevel {
@@ -1,9 +1,12 @@
 package helpers
 
 import (
+	"crypto/ed25519"
 	"fmt"
 	"net"
+	"slices"
 	"sort"
+	"strconv"
 	"sync"
 	"time"
 
@@ -11,45 +14,142 @@ import (
 	"github.com/lsig/OverlayNetwork/messages/types"
 	"github.com/lsig/OverlayNetwork/messages/utils"
 	pb "github.com/lsig/OverlayNetwork/pb"
+	"github.com/lsig/OverlayNetwork/transport"
+	"google.golang.org/protobuf/proto"
 )
 
 // Creates Listener Node object, containing:
 // Id
 // Address
+// Transport
 // Listener
 // Stats
-func CreateListenerNode() (*types.NodeInfo, error) {
-	port := utils.GenerateRandomPort()
-	// hardcoding the IP address only makes sense for this testing environment.
-	// With nodes covering multiple addresses, the external IP address should be used.
-	node := types.NodeInfo{Address: types.Address{Host: net.ParseIP("127.0.0.1"), Port: uint16(port)}, Listening: false, IsSetup: false}
-
-	// remove "localhost" if used externally.
-	// We explicitly prefix this to avoid firewall prompts on startup
-	listener, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", port))
+func CreateListenerNode(tr transport.Transport, bindHost string, advertiseHost string, port int) (*types.NodeInfo, error) {
+	// the address the node listens on may not be the one other nodes reach it at, e.g. on a host with several interfaces,
+	// so the registry and the other nodes are given the advertised address instead.
+	advertised, err := utils.ResolveHost(advertiseHost)
+	if err != nil {
+		return nil, fmt.Errorf("invalid advertised address: %s", err.Error())
+	}
+
+	// binding to localhost by default avoids firewall prompts on startup.
+	// With port 0 the OS picks a free port, which is the one advertised
+	listener, err := tr.Listen(net.JoinHostPort(bindHost, strconv.Itoa(port)))
 	if err != nil {
 		return nil, fmt.Errorf("error listening: %s", err.Error())
 	}
-	// logger.Infof("Listening on port %d", port)
+	_, listenPort, err := net.SplitHostPort(listener.Addr().String())
+	if err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("error reading the listening port: %s", err.Error())
+	}
+	port, _ = strconv.Atoi(listenPort)
+	logger.Debugf("Listening on %s, advertising %s", listener.Addr().String(), net.JoinHostPort(advertised.String(), listenPort))
+
+	// a node bound to one of several addresses of its host, such as 127.0.0.2 of the loopback network,
+	// connects from that address as well, as the registry and its peers check where connections come from
+	if ip := net.ParseIP(bindHost); ip != nil && !ip.IsUnspecified() {
+		if err := transport.SetSource(tr, ip); err != nil && !ip.Equal(net.IPv4(127, 0, 0, 1)) {
+			listener.Close()
+			return nil, fmt.Errorf("error listening on %s: %s", bindHost, err.Error())
+		}
+	}
+
+	node := types.NodeInfo{Address: types.Address{Host: advertised, Port: uint16(port)}, BindHost: bindHost, Transport: tr, Links: map[int32]*types.Link{}, SentTo: map[int32]uint32{}, RecvFrom: map[int32]uint32{}, Sequences: map[int32]uint64{}, Replays: map[int32]*types.ReplayWindow{}, Listening: false, IsSetup: false}
 
 	node.Listener = listener
 	node.Listening = true
 	return &node, nil
 }
 
-// Sets up network object containing:
-func SetupNetwork(nodeRegistry *pb.NodeRegistry, node *types.NodeInfo) (*types.Network, error) {
-	network := types.Network{}
+// The host a node listening on bindHost is reached at, unless it's told otherwise.
+// A node listening on every address of its host is reached at the loopback address.
+func AdvertisedHost(bindHost string) string {
+	ip := net.ParseIP(bindHost)
+	switch {
+	case bindHost == "" || (ip != nil && ip.Equal(net.IPv4zero)):
+		return "127.0.0.1"
+	case ip != nil && ip.IsUnspecified():
+		return "::1"
+	default:
+		return bindHost
+	}
+}
+
+// Opens the socket for the udp data plane on the host and port number of the listener,
+// the address the registry hands out to the node's peers.
+// It's only opened once the registry picks the udp data plane, which needs a transport on IP addresses.
+func openDatagrams(node *types.NodeInfo) error {
+	if node.Datagrams != nil {
+		return nil
+	}
+	if !transport.UsesIP(node.Transport) {
+		return fmt.Errorf("the udp data plane can't be used with the %s transport", node.Transport.Name())
+	}
+
+	_, listenPort, err := net.SplitHostPort(node.Listener.Addr().String())
+	if err != nil {
+		return fmt.Errorf("error reading the listening port: %s", err.Error())
+	}
+	udpAddress, err := net.ResolveUDPAddr("udp", net.JoinHostPort(node.BindHost, listenPort))
+	if err != nil {
+		return fmt.Errorf("error opening datagram socket: %s", err.Error())
+	}
+	datagrams, err := net.ListenUDP("udp", udpAddress)
+	if err != nil {
+		return fmt.Errorf("error opening datagram socket: %s", err.Error())
+	}
+	node.Datagrams = datagrams
+	return nil
+}
+
+// Creates the network object, which is set up once the registry has sent the routing table
+func NewNetwork(queueSize int) *types.Network {
+	return &types.Network{SendChannel: make(chan *pb.NodeData, queueSize), Relays: make(chan struct{})}
+}
+
+// Sets up network object from the NodeRegistry, replacing any earlier setup
+func SetupNetwork(network *types.Network, nodeRegistry *pb.NodeRegistry, node *types.NodeInfo) error {
+	TeardownNetwork(network)
+	network.RoutingTable = nil
+	network.Nodes = nil
 
 	for _, peer := range nodeRegistry.Peers {
 		peerAddress, err := utils.GetAddressFromString(peer.Address)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		externalNode := types.ExternalNode{Id: peer.Id, Address: *peerAddress}
 		network.RoutingTable = append(network.RoutingTable, &externalNode)
 	}
 
+	previousKeys := network.PublicKeys
+	network.PublicKeys = map[int32]ed25519.PublicKey{}
+	network.Members = map[int32]types.Address{}
+	network.Identities = map[int32]string{}
+	for _, identity := range nodeRegistry.Nodes {
+		if identity.Id != node.Id {
+			memberAddress, err := utils.GetAddressFromString(identity.Address)
+			if err != nil {
+				return err
+			}
+			network.Members[identity.Id] = *memberAddress
+			network.Identities[identity.Id] = identity.Name
+		}
+		if len(identity.PublicKey) == ed25519.PublicKeySize {
+			network.PublicKeys[identity.Id] = ed25519.PublicKey(identity.PublicKey)
+		}
+	}
+
+	// a node that registered again under the same id counts its packets from 1 again
+	node.RecvLock.Lock()
+	for id := range node.Replays {
+		if !slices.Equal(network.PublicKeys[id], previousKeys[id]) {
+			delete(node.Replays, id)
+		}
+	}
+	node.RecvLock.Unlock()
+
 	for _, id := range nodeRegistry.Ids {
 		if id != node.Id {
 			network.Nodes = append(network.Nodes, id)
@@ -62,22 +162,139 @@ func SetupNetwork(nodeRegistry *pb.NodeRegistry, node *types.NodeInfo) (*types.N
 		return network.RoutingTable[i].Id < network.RoutingTable[j].Id
 	})
 
-	// choose a random buffer size
-	// can't see how finding the exact size matters a whole lot as there isn't a "perfect" buffer size here
-	network.SendChannel = make(chan *pb.NodeData, 8)
-	return &network, nil
+	network.DataPlane = nodeRegistry.DataPlane
+	if network.DataPlane == types.DatagramDataPlane {
+		if err := openDatagrams(node); err != nil {
+			return err
+		}
+		for _, peer := range network.RoutingTable {
+			peer.UDPAddress = &net.UDPAddr{IP: peer.Address.Host, Port: int(peer.Address.Port)}
+		}
+	} else if network.DataPlane != types.StreamDataPlane {
+		return fmt.Errorf("unknown data plane %q", network.DataPlane)
+	}
+	return nil
+}
+
+// Closes the connections to the neighbours of an earlier setup
+func TeardownNetwork(network *types.Network) {
+	for _, peer := range network.RoutingTable {
+		if peer.Connection != nil {
+			peer.Connection.Close()
+		}
+	}
+}
+
+// Stops accepting connections and relaying packets, so the node's goroutines can finish
+func CloseNode(node *types.NodeInfo, network *types.Network) {
+	node.HasClosed = true
+
+	// no new connections can be made to this node
+	node.Listening = false
+	node.Listener.Close()
+	if node.Datagrams != nil {
+		node.Datagrams.Close()
+	}
+
+	// Close packet channel, node won't relay any more messages
+	close(network.SendChannel)
 }
 
-// creates fake packets and sends onto network channel
-func CreatePackets(node *types.NodeInfo, network *types.Network, packets uint32) {
+// creates fake packets and sends onto network channel, until stop is closed
+func CreatePackets(node *types.NodeInfo, network *types.Network, packets uint32, workload string, stop chan struct{}) {
 	for range packets {
 		// logger.Debug("adding packet to channel...")
-		packet := pb.NodeData{Destination: utils.GetRandomNode(network.Nodes), Source: node.Id, Payload: utils.GeneratePayload(), Hops: 0, Trace: []int32{}}
-		network.SendChannel <- &packet
+		packet := pb.NodeData{Destination: utils.PickDestination(network.Nodes, node.Id, workload), Source: node.Id, Payload: utils.GeneratePayload(), Hops: 0, Trace: []int32{}}
+		if node.SigningKey != nil {
+			node.SendLock.Lock()
+			node.Sequences[packet.Destination]++
+			packet.Sequence = node.Sequences[packet.Destination]
+			node.SendLock.Unlock()
+			utils.SignNodeData(node.SigningKey, &packet)
+		}
+		select {
+		case network.SendChannel <- &packet:
+		case <-stop:
+			return
+		}
 	}
 	// logger.Debugf("%d packets added to channel", packets)
 }
 
+// How long an aborted task may take to send the packets still queued
+var DrainTimeout = 5 * time.Second
+
+// How long an aborted node keeps counting the packets still in flight before it reports
+var AbortSettleTime = 1 * time.Second
+
+// Waits for the packets in the send channel to be sent, and returns how many were left after DrainTimeout
+func DrainQueue(network *types.Network) int {
+	deadline := time.Now().Add(DrainTimeout)
+	for len(network.SendChannel) > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	return len(network.SendChannel)
+}
+
+// Returns the channel that is closed once the packets relayed now no longer belong to the running task
+func RelayEpoch(network *types.Network) chan struct{} {
+	network.RelayLock.Lock()
+	defer network.RelayLock.Unlock()
+	return network.Relays
+}
+
+// Drops the packets still waiting for room in the send channel to be relayed,
+// as the task they belong to was stopped
+func EndRelayEpoch(network *types.Network) {
+	network.RelayLock.Lock()
+	defer network.RelayLock.Unlock()
+	close(network.Relays)
+	network.Relays = make(chan struct{})
+}
+
+// Drops the packets in the send channel, and returns how many there were
+func DiscardQueue(network *types.Network) int {
+	discarded := 0
+	for {
+		select {
+		case <-network.SendChannel:
+			discarded++
+		default:
+			return discarded
+		}
+	}
+}
+
+// Copies the node's counters, which the goroutines sending packets update under SendLock,
+// and the ones receiving them under RecvLock
+func StatsSnapshot(node *types.NodeInfo) *pb.TrafficSummary {
+	node.SendLock.Lock()
+	node.RecvLock.Lock()
+	defer node.SendLock.Unlock()
+	defer node.RecvLock.Unlock()
+	return &pb.TrafficSummary{Sent: node.Stats.Sent, Relayed: node.Stats.Relayed, Received: node.Stats.Received, TotalSent: node.Stats.TotalSent, TotalReceived: node.Stats.TotalReceived, Forged: node.Stats.Forged, Hops: node.Stats.Hops}
+}
+
+// Zeroes the counters of the last task, before the next one starts
+func ResetStats(node *types.NodeInfo) {
+	node.SendLock.Lock()
+	node.RecvLock.Lock()
+	proto.Reset(&node.Stats)
+	node.SentTo = map[int32]uint32{}
+	node.RecvFrom = map[int32]uint32{}
+	node.RecvLock.Unlock()
+	node.SendLock.Unlock()
+
+	node.LinkLock.Lock()
+	node.Links = map[int32]*types.Link{}
+	node.LinkLock.Unlock()
+
+	node.TimeLock.Lock()
+	node.Timeline = nil
+	node.TaskStart = time.Time{}
+	node.TimeLock.Unlock()
+}
+
 // Continuously scans the stdin for user commands
 // and performs actions based on the recieved command
 func HandleStdInput(wg *sync.WaitGroup, node *types.NodeInfo, registry *types.Registry) {
@@ -103,10 +320,10 @@ func HandleStdInput(wg *sync.WaitGroup, node *types.NodeInfo, registry *types.Re
 					logger.Error("Can't deregister, node is already setup")
 					break
 				}
-				deregistration := pb.Deregistration{Id: node.Id, Address: node.Address.ToString()}
+				deregistration := pb.Deregistration{Id: node.Id, Address: node.Address.ToString(), Token: registry.Token}
 
 				chord := pb.MiniChord{Message: &pb.MiniChord_Deregistration{Deregistration: &deregistration}}
-				err := utils.SendMessage(registry.Connection, &chord)
+				err := SendToRegistry(registry, &chord)
 				if err != nil {
 					fmt.Printf("ERROR: Error when deregistering: %v\n", err.Error())
 				} else {
@@ -122,11 +339,13 @@ func HandleStdInput(wg *sync.WaitGroup, node *types.NodeInfo, registry *types.Re
 					}
 				}
 			case "print":
-				fmt.Printf("Sent %d\n", node.Stats.Sent)
-				fmt.Printf("Received %d\n", node.Stats.Received)
-				fmt.Printf(" Relayed %d\n", node.Stats.Relayed)
-				fmt.Printf("Total Sent %d\n", node.Stats.TotalSent)
-				fmt.Printf("Total Received %d\n", node.Stats.TotalReceived)
+				stats := StatsSnapshot(node)
+				fmt.Printf("Sent %d\n", stats.Sent)
+				fmt.Printf("Received %d\n", stats.Received)
+				fmt.Printf(" Relayed %d\n", stats.Relayed)
+				fmt.Printf("Total Sent %d\n", stats.TotalSent)
+				fmt.Printf("Total Received %d\n", stats.TotalReceived)
+				fmt.Printf("Forged %d\n", stats.Forged)
 			default:
 				fmt.Println("unknown command...")
 			}
@@ -137,16 +356,12 @@ func HandleStdInput(wg *sync.WaitGroup, node *types.NodeInfo, registry *types.Re
 	logger.Info("stopped listening to commands")
 }
 
+// HandleRegistry routes the response here, as the main flow may be waiting for the registry at the same time
 func GetDeregistrationResponse(registry *types.Registry) (*pb.DeregistrationResponse, error) {
-	response, err := utils.ReceiveMessage(registry.Connection)
-	if err != nil {
+	select {
+	case response := <-registry.Deregistrations:
+		return response, nil
+	case <-time.After(time.Duration(ResumeAttempts) * ResumeInterval):
 		return nil, fmt.Errorf("deregistration failed")
 	}
-
-	deregistrationResponse, ok := response.GetMessage().(*pb.MiniChord_DeregistrationResponse)
-	if !ok {
-		return nil, fmt.Errorf("deregistration failed")
-	}
-
-	return deregistrationResponse.DeregistrationResponse, nil
 }
@@ -0,0 +1,24 @@
+package helpers
+
+import "testing"
+
+func TestAdvertisedHost(t *testing.T) {
+	tests := []struct {
+		bindHost   string
+		advertised string
+	}{
+		{bindHost: "localhost", advertised: "localhost"},
+		{bindHost: "127.0.0.2", advertised: "127.0.0.2"},
+		{bindHost: "::1", advertised: "::1"},
+		{bindHost: "10.0.0.2", advertised: "10.0.0.2"},
+		{bindHost: "0.0.0.0", advertised: "127.0.0.1"},
+		{bindHost: "::", advertised: "::1"},
+		{bindHost: "", advertised: "127.0.0.1"},
+	}
+
+	for _, test := range tests {
+		if advertised := AdvertisedHost(test.bindHost); advertised != test.advertised {
+			t.Errorf("AdvertisedHost(%q) = %q, want %q", test.bindHost, advertised, test.advertised)
+		}
+	}
+}
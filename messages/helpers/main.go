@@ -0,0 +1,196 @@
+package helpers
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"flag"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/lsig/OverlayNetwork/auth"
+	"github.com/lsig/OverlayNetwork/config"
+	"github.com/lsig/OverlayNetwork/logger"
+	"github.com/lsig/OverlayNetwork/messages/utils"
+	"github.com/lsig/OverlayNetwork/transport"
+)
+
+// Everything a node can be configured with, by flags or a json config file
+type Config struct {
+	Registry         string          `json:"registry"`
+	Host             string          `json:"host"`
+	Advertise        string          `json:"advertise"`
+	Port             int             `json:"port"`
+	Transport        string          `json:"transport"`
+	TLSCA            string          `json:"tls_ca"`
+	TLSCert          string          `json:"tls_cert"`
+	TLSKey           string          `json:"tls_key"`
+	Sign             bool            `json:"sign"`
+	PSK              string          `json:"psk"`
+	LogLevel         string          `json:"log_level"`
+	QueueSize        int             `json:"queue_size"`
+	SendDelay        config.Duration `json:"send_delay"`
+	DrainTimeout     config.Duration `json:"drain_timeout"`
+	AbortSettleTime  config.Duration `json:"abort_settle_time"`
+	ProgressInterval config.Duration `json:"progress_interval"`
+	ResumeAttempts   int             `json:"resume_attempts"`
+	ResumeInterval   config.Duration `json:"resume_interval"`
+	Seed             uint64          `json:"seed"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Host:             "localhost",
+		Transport:        "tcp",
+		LogLevel:         "info",
+		QueueSize:        8,
+		SendDelay:        config.Duration{Duration: SendDelay},
+		DrainTimeout:     config.Duration{Duration: DrainTimeout},
+		AbortSettleTime:  config.Duration{Duration: AbortSettleTime},
+		ProgressInterval: config.Duration{Duration: ProgressInterval},
+		ResumeAttempts:   ResumeAttempts,
+		ResumeInterval:   config.Duration{Duration: ResumeInterval},
+	}
+}
+
+// Runs a messaging node configured by the command line arguments in args, until it leaves the overlay
+func Main(args []string) {
+	flags := flag.NewFlagSet("node", flag.ExitOnError)
+
+	// create waitgroup to keep program executing
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+
+	cfg := DefaultConfig()
+	flags.StringVar(&cfg.Host, "host", cfg.Host, "host or ip address the node listens on")
+	flags.StringVar(&cfg.Advertise, "advertise", cfg.Advertise, "ip address or host name the registry and other nodes reach the node at, the host it listens on by default")
+	flags.IntVar(&cfg.Port, "port", cfg.Port, "port the node listens on, 0 lets the OS pick a free one")
+	flags.StringVar(&cfg.Transport, "transport", cfg.Transport, "transport used to reach the registry and other nodes: "+strings.Join(transport.Names, ", "))
+	flags.StringVar(&cfg.TLSCA, "tls-ca", cfg.TLSCA, "CA certificate for mutual tls, see certs/certs.go")
+	flags.StringVar(&cfg.TLSCert, "tls-cert", cfg.TLSCert, "certificate presented to the registry and other nodes")
+	flags.StringVar(&cfg.TLSKey, "tls-key", cfg.TLSKey, "private key of the tls certificate")
+	flags.BoolVar(&cfg.Sign, "sign", cfg.Sign, "sign every packet this node sends, so destinations can detect spoofed sources")
+	flags.StringVar(&cfg.PSK, "psk", cfg.PSK, "file holding the pre-shared key the registry requires, if any")
+	flags.StringVar(&cfg.LogLevel, "log-level", cfg.LogLevel, "least severe messages logged: "+strings.Join(logger.LevelNames, ", "))
+	flags.IntVar(&cfg.QueueSize, "queue-size", cfg.QueueSize, "packets waiting to be sent before creating more blocks")
+	flags.DurationVar(&cfg.SendDelay.Duration, "send-delay", cfg.SendDelay.Duration, "pause after sending each packet, so the network isn't overloaded")
+	flags.DurationVar(&cfg.DrainTimeout.Duration, "drain-timeout", cfg.DrainTimeout.Duration, "how long an aborted task may take to send the packets still queued")
+	flags.DurationVar(&cfg.AbortSettleTime.Duration, "abort-settle-time", cfg.AbortSettleTime.Duration, "how long an aborted node counts the packets still in flight before it reports")
+	flags.DurationVar(&cfg.ProgressInterval.Duration, "progress-interval", cfg.ProgressInterval.Duration, "how often the node reports its progress while a task runs")
+	flags.IntVar(&cfg.ResumeAttempts, "resume-attempts", cfg.ResumeAttempts, "how often the node tries to get back to the registry after losing its connection")
+	flags.DurationVar(&cfg.ResumeInterval.Duration, "resume-interval", cfg.ResumeInterval.Duration, "how far apart the attempts to get back to the registry are")
+	flags.Uint64Var(&cfg.Seed, "seed", cfg.Seed, "seed for the destinations and payloads of the packets, 0 picks one at random")
+
+	if err := config.Parse(flags, args, &cfg); err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	// the registry given on the command line overrides the config file's
+	registryArgs := flags.Args()
+	if len(registryArgs) == 0 && cfg.Registry != "" {
+		registryArgs = []string{cfg.Registry}
+	}
+	if len(registryArgs) == 1 {
+		cfg.Registry = registryArgs[0]
+	}
+
+	if cfg.Advertise == "" {
+		cfg.Advertise = AdvertisedHost(cfg.Host)
+	}
+
+	level, err := logger.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	logger.SetLevel(level)
+
+	if cfg.QueueSize < 1 || cfg.ProgressInterval.Duration <= 0 {
+		logger.Error("The queue size and progress interval must be positive")
+		os.Exit(1)
+	}
+	SendDelay = cfg.SendDelay.Duration
+	DrainTimeout = cfg.DrainTimeout.Duration
+	AbortSettleTime = cfg.AbortSettleTime.Duration
+	ProgressInterval = cfg.ProgressInterval.Duration
+	ResumeAttempts = cfg.ResumeAttempts
+	ResumeInterval = cfg.ResumeInterval.Duration
+	if cfg.Seed != 0 {
+		utils.Seed(int64(cfg.Seed))
+	}
+
+	registry, err := utils.GetRegistryFromProgramArgs(registryArgs)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	config.Print("Node", cfg)
+
+	if cfg.PSK != "" {
+		if registry.SharedKey, err = auth.LoadKey(cfg.PSK); err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+	}
+
+	tr, err := transport.Setup(cfg.Transport, cfg.TLSCA, cfg.TLSCert, cfg.TLSKey)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	// create Listener Node
+	node, err := CreateListenerNode(tr, cfg.Host, cfg.Advertise, cfg.Port)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	defer node.Listener.Close()
+
+	if cfg.Sign {
+		if _, node.SigningKey, err = ed25519.GenerateKey(nil); err != nil {
+			logger.Errorf("error generating signing key: %s", err.Error())
+			os.Exit(1)
+		}
+	}
+
+	// handle standard input commands from user
+	go HandleStdInput(&wg, node, registry)
+
+	// Connect to registry
+	if err = ConnectToRegistry(node, registry); err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	// send Registration
+	registrationResponse, err := Register(node, registry)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	node.Id = registrationResponse.Result
+
+	// from now on, all messages from the registry go through this goroutine
+	go HandleRegistry(node, registry)
+
+	network := NewNetwork(cfg.QueueSize)
+
+	// handle sending packets
+	go HandleConnector(&wg, node, network)
+
+	// set up, accept incoming connections, run tasks and report, as the registry requests.
+	// The node stays in the overlay for as many tasks as the registry runs, until the registry goes away
+	err = HandleRegistryRequests(&wg, node, network, registry)
+	if errors.Is(err, ErrShutdown) {
+		logger.Infof("leaving the overlay: %s", err.Error())
+	} else {
+		logger.Warningf("leaving the overlay: %s", err.Error())
+	}
+
+	CloseNode(node, network)
+	wg.Wait()
+
+	logger.Info("I'm done now... bye")
+}
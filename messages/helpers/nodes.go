@@ -1,9 +1,10 @@
 package helpers
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"net"
-	"os"
 	"strings"
 	"sync"
 	"time"
@@ -12,9 +13,15 @@ import (
 	"github.com/lsig/OverlayNetwork/messages/types"
 	"github.com/lsig/OverlayNetwork/messages/utils"
 	pb "github.com/lsig/OverlayNetwork/pb"
+	"google.golang.org/protobuf/proto"
 )
 
-func ConnectToNeighbours(network *types.Network) {
+func ConnectToNeighbours(node *types.NodeInfo, network *types.Network) {
+	if network.DataPlane == types.DatagramDataPlane {
+		// datagrams are connectionless, peers only need to be resolved, which SetupNetwork already did
+		return
+	}
+
 	// create a waitgroup, so that the function doesn't exit unless all neighbours have been connected to.
 	wg := sync.WaitGroup{}
 
@@ -25,20 +32,25 @@ func ConnectToNeighbours(network *types.Network) {
 	for _, peer := range network.RoutingTable {
 		go func(p *types.ExternalNode, wg *sync.WaitGroup) {
 			// dial peer until connection is made
-			tcpServer, err := net.ResolveTCPAddr("tcp", p.Address.ToString())
-			if err != nil {
-				logger.Errorf("error creating tcp connection to messaging node: %s\naborting...", err.Error())
-				return
-			}
 			tries := 10
 			for p.Connection == nil && tries >= 0 {
 				if tries <= 0 {
 					logger.Errorf("Could not connect to neighbour %s", p.Address.ToString())
 					break
 				}
-				conn, err := net.DialTCP("tcp", nil, tcpServer)
+				conn, err := node.Transport.Dial(p.Address.ToString())
 				if err != nil {
 					// logger.Errorf("error dialing messaging node: %s", err.Error())
+				} else if matches, reason := utils.PeerCertificateMatches(p.Id, conn, network); !matches {
+					// whoever listens on the neighbour's address isn't going to change either
+					logger.Errorf("Neighbour %d failed verification: %s", p.Id, reason)
+					conn.Close()
+					break
+				} else if err := SendPeerHandshake(conn, node, p); err != nil {
+					// being rejected isn't going to change by dialing again
+					logger.Errorf("Handshake with neighbour %d failed: %s", p.Id, err.Error())
+					conn.Close()
+					break
 				} else {
 					p.Connection = conn
 					// logger.Infof("Connected to node %d", p.Id)
@@ -54,6 +66,14 @@ func ConnectToNeighbours(network *types.Network) {
 // Handles each receiving connection from other message nodes
 // runs in a separate goroutine
 func HandleNodeConnection(conn net.Conn, node *types.NodeInfo, network *types.Network) {
+	peer, err := AcceptPeerHandshake(conn, node, network)
+	if err != nil {
+		logger.Warningf("rejected connection from %s: %s", conn.RemoteAddr().String(), err.Error())
+		conn.Close()
+		return
+	}
+	logger.Debugf("accepted connection from node %d", peer)
+
 	for {
 		chord, err := utils.ReceiveMessage(conn)
 		if err != nil {
@@ -72,33 +92,217 @@ func HandleNodeConnection(conn net.Conn, node *types.NodeInfo, network *types.Ne
 			break
 		}
 
-		nodeData := nr.NodeData
+		node.LinkLock.Lock()
+		link := GetLink(node, peer)
+		link.PacketsReceived++
+		link.BytesReceived += uint64(utils.I64SIZE + proto.Size(chord))
+		node.LinkLock.Unlock()
 
-		if malformed, _ := utils.NodeDataPacketIsMalformed(nodeData, node); malformed {
-			logger.Warningf("received malformed packet, dropping: %v", nodeData)
+		if err := HandleNodeData(nr.NodeData, node, network); err != nil {
+			logger.Warningf("%s, dropping: %v", err.Error(), nr.NodeData)
 			break
 		}
+	}
+}
+
+// Receives NodeData datagrams from other message nodes when the udp data plane is used.
+// Runs in a separate goroutine until the datagram socket is closed, when the node exits
+func HandleDatagrams(node *types.NodeInfo, network *types.Network) {
+	socket := node.Datagrams
+
+	buffer := make([]byte, utils.MaxDatagramSize)
+	for {
+		datagram, from, err := utils.ReceiveDatagram(socket, buffer)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				logger.Info("Datagram socket closed. Exiting receive loop.")
+				break
+			}
+			logger.Errorf("error receiving datagram from node: %s", err.Error())
+			continue
+		}
+
+		// datagrams have no handshake, so every single one is checked against the overlay's members
+		if admitted, reason := utils.PeerIsAdmitted(datagram.Sender, from.String(), network); !admitted {
+			logger.Warningf("rejected datagram from %s: %s", from.String(), reason)
+			continue
+		}
+
+		node.LinkLock.Lock()
+		link := GetLink(node, datagram.Sender)
+		if link.DatagramsReceived > 0 && datagram.Sequence <= link.HighestSequence {
+			// an earlier datagram overtook this one
+			link.DatagramsReordered++
+		} else {
+			link.HighestSequence = datagram.Sequence
+		}
+		link.DatagramsReceived++
+		link.PacketsReceived++
+		link.BytesReceived += uint64(proto.Size(datagram))
+		node.LinkLock.Unlock()
+
+		if err := HandleNodeData(datagram.Data, node, network); err != nil {
+			logger.Warningf("%s, dropping: %v", err.Error(), datagram.Data)
+		}
+	}
+}
 
-		if nodeData.Destination == node.Id {
+// Either counts a NodeData packet as received, or passes it on for relaying
+func HandleNodeData(nodeData *pb.NodeData, node *types.NodeInfo, network *types.Network) error {
+	if nodeData == nil {
+		return fmt.Errorf("received empty packet")
+	}
+
+	if malformed, reason := utils.NodeDataPacketIsMalformed(nodeData, node); malformed {
+		return fmt.Errorf("received malformed packet (%s)", reason)
+	}
+
+	if nodeData.Destination == node.Id {
+		if !utils.NodeDataSignatureIsValid(nodeData, network.PublicKeys) {
 			node.RecvLock.Lock()
-			// this packet is for me!
-			node.Stats.Received++
-			node.Stats.TotalReceived += int64(nodeData.Payload)
-			// logger.Debugf("received NodeData message: %v", nodeData)
+			node.Stats.Forged++
 			node.RecvLock.Unlock()
-		} else {
-			node.Stats.Relayed++
-			// TODO check if my id appears in the trace.
-			nodeData.Trace = append(nodeData.Trace, node.Id)
-			// logger.Debugf("relaying NodeData message: %v", nodeData)
-			// add to channel in a separate goroutine,
-			// as we don't want the existing goroutine to be blocked from receiving new messages
-			// if the channel is full
-			go func(nw *types.Network, nd *pb.NodeData) {
-				nw.SendChannel <- nd
-			}(network, nodeData)
+			// whoever passed this packet on isn't necessarily the forger, so keep the connection open
+			logger.Warningf("received packet with invalid signature from source %d, dropping", nodeData.Source)
+			return nil
 		}
+
+		node.RecvLock.Lock()
+		if utils.NodeDataIsReplayed(nodeData, node, network.PublicKeys) {
+			node.Stats.Forged++
+			node.RecvLock.Unlock()
+			logger.Warningf("received replayed packet %d from source %d, dropping", nodeData.Sequence, nodeData.Source)
+			return nil
+		}
+		// this packet is for me!
+		node.Stats.Received++
+		node.Stats.TotalReceived += int64(nodeData.Payload)
+		node.RecvFrom[nodeData.Source]++
+		node.Stats.Hops += uint64(nodeData.Hops)
+		// logger.Debugf("received NodeData message: %v", nodeData)
+		node.RecvLock.Unlock()
+
+		node.TimeLock.Lock()
+		GetSecond(node).Received++
+		node.TimeLock.Unlock()
+	} else {
+		node.RecvLock.Lock()
+		node.Stats.Relayed++
+		node.RecvLock.Unlock()
+
+		node.TimeLock.Lock()
+		GetSecond(node).Relayed++
+		node.TimeLock.Unlock()
+
+		// TODO check if my id appears in the trace.
+		nodeData.Trace = append(nodeData.Trace, node.Id)
+		nodeData.Hops++
+		// logger.Debugf("relaying NodeData message: %v", nodeData)
+		// add to channel in a separate goroutine,
+		// as we don't want the existing goroutine to be blocked from receiving new messages
+		// if the channel is full, unless its task is stopped before there is room
+		go func(nw *types.Network, nd *pb.NodeData, epoch chan struct{}) {
+			select {
+			case nw.SendChannel <- nd:
+			case <-epoch:
+				logger.Debugf("dropping packet from %d to %d of a stopped task", nd.Source, nd.Destination)
+			}
+		}(network, nodeData, RelayEpoch(network))
+	}
+	return nil
+}
+
+// Returns the link counters for a peer, creating them on first use.
+// node.LinkLock must be held by the caller
+func GetLink(node *types.NodeInfo, peer int32) *types.Link {
+	link, ok := node.Links[peer]
+	if !ok {
+		link = &types.Link{Peer: peer}
+		node.Links[peer] = link
 	}
+	return link
+}
+
+// Returns the timeline counters of the current second, starting the timeline on first use.
+// node.TimeLock must be held by the caller
+func GetSecond(node *types.NodeInfo) *types.Second {
+	now := time.Now()
+	if node.TaskStart.IsZero() {
+		node.TaskStart = now
+	}
+
+	second := int(now.Sub(node.TaskStart) / time.Second)
+	for len(node.Timeline) <= second {
+		node.Timeline = append(node.Timeline, types.Second{})
+	}
+	return &node.Timeline[second]
+}
+
+// How long either side of a peer handshake waits for the other one
+const HandshakeTimeout = 5 * time.Second
+
+// Announces this node's Id to a neighbour it has just dialed,
+// and waits for the neighbour to admit it
+func SendPeerHandshake(conn net.Conn, node *types.NodeInfo, peer *types.ExternalNode) error {
+	handshake := pb.PeerHandshake{Id: node.Id, Address: node.Address.ToString()}
+	chord := pb.MiniChord{Message: &pb.MiniChord_PeerHandshake{PeerHandshake: &handshake}}
+	if err := utils.SendMessage(conn, &chord); err != nil {
+		return err
+	}
+
+	conn.SetReadDeadline(time.Now().Add(HandshakeTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+
+	response, err := utils.ReceiveMessage(conn)
+	if err != nil {
+		return fmt.Errorf("error receiving handshake response: %s", err.Error())
+	}
+
+	nr, ok := response.GetMessage().(*pb.MiniChord_PeerHandshakeResponse)
+	if !ok {
+		return fmt.Errorf("error when parsing peerHandshakeResponse packet")
+	}
+	if nr.PeerHandshakeResponse.Result != peer.Id {
+		return fmt.Errorf("rejected: %s", nr.PeerHandshakeResponse.Info)
+	}
+	return nil
+}
+
+// Reads the handshake a dialing node must open its connection with,
+// and admits the node if it's part of the overlay, connects from the host it registered with and, with tls, presents the certificate it registered with.
+// Returns the Id of the admitted node.
+func AcceptPeerHandshake(conn net.Conn, node *types.NodeInfo, network *types.Network) (int32, error) {
+	conn.SetReadDeadline(time.Now().Add(HandshakeTimeout))
+	chord, err := utils.ReceiveMessage(conn)
+	conn.SetReadDeadline(time.Time{})
+	if err != nil {
+		return -1, fmt.Errorf("error receiving handshake: %s", err.Error())
+	}
+
+	nr, ok := chord.GetMessage().(*pb.MiniChord_PeerHandshake)
+	if !ok {
+		return -1, fmt.Errorf("expected a PeerHandshake, got %s", utils.GetMiniChordType(chord))
+	}
+
+	peer := nr.PeerHandshake.Id
+	admitted, reason := utils.PeerIsAdmitted(peer, conn.RemoteAddr().String(), network)
+	if admitted {
+		admitted, reason = utils.PeerCertificateMatches(peer, conn, network)
+	}
+
+	response := pb.PeerHandshakeResponse{Result: node.Id, Info: fmt.Sprintf("node %d accepts node %d", node.Id, peer)}
+	if !admitted {
+		response = pb.PeerHandshakeResponse{Result: -1, Info: reason}
+	}
+	responseChord := pb.MiniChord{Message: &pb.MiniChord_PeerHandshakeResponse{PeerHandshakeResponse: &response}}
+	if err := utils.SendMessage(conn, &responseChord); err != nil {
+		return -1, err
+	}
+
+	if !admitted {
+		return -1, fmt.Errorf("%s", reason)
+	}
+	return peer, nil
 }
 
 // Accepts incoming connections from other message nodes
@@ -121,6 +325,9 @@ func HandleListener(wg *sync.WaitGroup, node *types.NodeInfo, network *types.Net
 	logger.Info("Node is no longer listening")
 }
 
+// How long the node waits after sending each packet
+var SendDelay = 1 * time.Millisecond
+
 // Receives packets from the packet channel
 // and finds the optimal neighbour to send to
 func HandleConnector(wg *sync.WaitGroup, node *types.NodeInfo, network *types.Network) {
@@ -139,21 +346,52 @@ func HandleConnector(wg *sync.WaitGroup, node *types.NodeInfo, network *types.Ne
 			// This packet originated at my node
 			node.Stats.Sent++
 			node.Stats.TotalSent += int64(packet.Payload)
+			node.SentTo[packet.Destination]++
 			node.SendLock.Unlock()
+
+			node.TimeLock.Lock()
+			GetSecond(node).Sent++
+			node.TimeLock.Unlock()
+		}
+
+		queueDepth := uint32(len(network.SendChannel))
+
+		var size int
+		var err error
+		if network.DataPlane == types.DatagramDataPlane {
+			node.LinkLock.Lock()
+			link := GetLink(node, bestNeighbour.Id)
+			datagram := pb.NodeDatagram{Sender: node.Id, Sequence: link.DatagramsSent, Data: packet}
+			link.DatagramsSent++
+			node.LinkLock.Unlock()
+
+			size = proto.Size(&datagram)
+			err = utils.SendDatagram(node.Datagrams, bestNeighbour.UDPAddress, &datagram)
+		} else {
+			size = utils.I64SIZE + proto.Size(&chord)
+			err = utils.SendMessage(bestNeighbour.Connection, &chord)
+		}
+
+		node.LinkLock.Lock()
+		link := GetLink(node, bestNeighbour.Id)
+		if err != nil {
+			link.SendErrors++
+		} else {
+			link.PacketsSent++
+			link.BytesSent += uint64(size)
 		}
+		link.MaxQueueDepth = max(link.MaxQueueDepth, queueDepth)
+		node.LinkLock.Unlock()
 
-		err := utils.SendMessage(bestNeighbour.Connection, &chord)
+		// the packet is lost, which shows up in the link's send errors
 		if err != nil {
 			logger.Errorf("error forwarding packet to node %d: %s", bestNeighbour.Id, err.Error())
-			os.Exit(1)
 		}
 
 		// VERY important sleep, as otherwise the network is overloaded.
-		time.Sleep(1 * time.Millisecond)
+		time.Sleep(SendDelay)
 	}
 	// time.Sleep()
 	logger.Infof("Finished sending packets.. closing connections")
-	for _, peer := range network.RoutingTable {
-		peer.Connection.Close()
-	}
+	TeardownNetwork(network)
 }
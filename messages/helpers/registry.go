@@ -1,39 +1,43 @@
 package helpers
 
 import (
+	"crypto/ed25519"
+	"errors"
 	"fmt"
-	"io"
-	"net"
 	"os"
+	"sync"
 	"time"
 
+	"github.com/lsig/OverlayNetwork/auth"
 	"github.com/lsig/OverlayNetwork/logger"
 	"github.com/lsig/OverlayNetwork/messages/types"
 	"github.com/lsig/OverlayNetwork/messages/utils"
 	pb "github.com/lsig/OverlayNetwork/pb"
+	"google.golang.org/protobuf/proto"
 )
 
-// connects to the registry using a provided address,
+// connects to the registry using a provided address and the node's transport,
 // and stores the connection in the registry struct
-func ConnectToRegistry(registry *types.Registry) error {
-	tcpServer, err := net.ResolveTCPAddr("tcp", registry.Address.ToString())
+func ConnectToRegistry(node *types.NodeInfo, registry *types.Registry) error {
+	connection, err := node.Transport.Dial(registry.Address.ToString())
 	if err != nil {
-		return fmt.Errorf("error creating tcp connection to registry: %s", err.Error())
-	}
-
-	connection, err := net.DialTCP("tcp", nil, tcpServer)
-	if err != nil {
-		return fmt.Errorf("error creating tcp connection to registry: %s", err.Error())
+		return fmt.Errorf("error creating %s connection to registry: %s", node.Transport.Name(), err.Error())
 	}
 	// logger.Info("connected to registry")
 
+	registry.Lock.Lock()
 	registry.Connection = connection
+	registry.Lock.Unlock()
 	return nil
 }
 
-// Registers node to registry and gets a node Id
+// Registers node to registry and gets a node Id.
+// If the node already holds a session token, the registration is resumed instead, keeping the Id.
 func Register(node *types.NodeInfo, registry *types.Registry) (*pb.RegistrationResponse, error) {
-	message := pb.Registration{Address: node.Address.ToString()}
+	message := pb.Registration{Address: node.Address.ToString(), Token: registry.Token}
+	if node.SigningKey != nil {
+		message.PublicKey = node.SigningKey.Public().(ed25519.PublicKey)
+	}
 	chord := pb.MiniChord{Message: &pb.MiniChord_Registration{Registration: &message}}
 
 	utils.SendMessage(registry.Connection, &chord)
@@ -42,131 +46,418 @@ func Register(node *types.NodeInfo, registry *types.Registry) (*pb.RegistrationR
 		return nil, fmt.Errorf("error receiving Registration Response: %s", err.Error())
 	}
 
+	// a registry using a pre-shared key challenges the node before answering
+	if challenge, ok := response.GetMessage().(*pb.MiniChord_AuthChallenge); ok {
+		if registry.SharedKey == nil {
+			return nil, fmt.Errorf("registry requires a pre-shared key, see -psk")
+		}
+
+		mac := auth.ChallengeResponse(registry.SharedKey, challenge.AuthChallenge.Nonce, message.Address)
+		authChord := pb.MiniChord{Message: &pb.MiniChord_AuthResponse{AuthResponse: &pb.AuthResponse{Mac: mac}}}
+		if err := utils.SendMessage(registry.Connection, &authChord); err != nil {
+			return nil, fmt.Errorf("error sending AuthResponse: %s", err.Error())
+		}
+
+		response, err = utils.ReceiveMessage(registry.Connection)
+		if err != nil {
+			return nil, fmt.Errorf("error receiving Registration Response: %s", err.Error())
+		}
+	}
+
 	nr, ok := response.GetMessage().(*pb.MiniChord_RegistrationResponse)
 	if !ok {
 		return nil, fmt.Errorf("error when parsing registrationResponse packet")
 	}
 
+	if nr.RegistrationResponse.Result == -1 {
+		return nil, fmt.Errorf("registration rejected: %s", nr.RegistrationResponse.Info)
+	}
+	registry.Token = nr.RegistrationResponse.Token
+
 	logger.Infof("my Id is: %d", nr.RegistrationResponse.Result)
 
 	return nr.RegistrationResponse, nil
 }
 
-// Gets the NodeRegistry request from the registry and returns it.
-func GetNodeRegistry(registry *types.Registry) (*pb.NodeRegistry, error) {
-	logger.Info("Waiting for NodeRegistry packet from registry...")
-	nodeRegistry, err := utils.ReceiveMessage(registry.Connection)
-	if err != nil {
-		if err == io.EOF {
-			return nil, fmt.Errorf("registry has crashed, shutting down")
+// How often, and how far apart, a node tries to get back to the registry after losing its connection
+var ResumeAttempts = 10
+var ResumeInterval = 1 * time.Second
+
+// Receives every message the registry sends after registration, and passes it on through registry.Messages.
+// A dropped connection isn't fatal, the node reconnects and resumes its session with its token.
+// Runs in a separate goroutine, and closes registry.Messages once the registry can't be reached anymore.
+func HandleRegistry(node *types.NodeInfo, registry *types.Registry) {
+	defer close(registry.Messages)
+
+	for {
+		chord, err := utils.ReceiveMessage(registry.GetConnection())
+		if err == nil {
+			if response, ok := chord.GetMessage().(*pb.MiniChord_DeregistrationResponse); ok {
+				// the stdin handler is waiting for this one, not the main flow
+				registry.Deregistrations <- response.DeregistrationResponse
+				continue
+			}
+			registry.Messages <- chord
+			continue
+		}
+
+		if node.HasClosed {
+			return
+		}
+
+		logger.Warningf("lost connection to registry: %s", err.Error())
+		if err := ResumeSession(node, registry); err != nil {
+			logger.Errorf("could not resume session with registry: %s", err.Error())
+			registry.Lost = true
+			return
 		}
-		return nil, fmt.Errorf("error receiving NodeRegistry packet from registry: %s", err.Error())
 	}
-	// logger.Infof("Received NodeRegistry packet from registry: %v", nodeRegistry)
+}
 
-	nr, ok := nodeRegistry.GetMessage().(*pb.MiniChord_NodeRegistry)
+// Reconnects to the registry and resumes the session this node registered earlier
+func ResumeSession(node *types.NodeInfo, registry *types.Registry) error {
+	for attempt := 1; attempt <= ResumeAttempts; attempt++ {
+		time.Sleep(ResumeInterval)
+
+		if err := ConnectToRegistry(node, registry); err != nil {
+			logger.Warningf("resume attempt %d/%d: %s", attempt, ResumeAttempts, err.Error())
+			continue
+		}
+
+		response, err := Register(node, registry)
+		if err != nil {
+			// the registry answered, but won't take the node back, so trying again won't help
+			return err
+		}
+
+		logger.Infof("resumed session with registry: %s", response.Info)
+		return nil
+	}
+	return fmt.Errorf("registry unreachable after %d attempts", ResumeAttempts)
+}
+
+// Waits for the next message from the registry
+func ReceiveFromRegistry(registry *types.Registry) (*pb.MiniChord, error) {
+	chord, ok := <-registry.Messages
 	if !ok {
-		return nil, fmt.Errorf("error when parsing nodeRegistry packet")
+		return nil, fmt.Errorf("lost connection to registry")
+	}
+	return chord, nil
+}
+
+// Sends a message to the registry. If the connection has dropped,
+// the message is sent again once HandleRegistry has resumed the session.
+func SendToRegistry(registry *types.Registry, chord *pb.MiniChord) error {
+	registry.SendLock.Lock()
+	defer registry.SendLock.Unlock()
+
+	conn := registry.GetConnection()
+	err := utils.SendMessage(conn, chord)
+
+	deadline := time.Now().Add(time.Duration(ResumeAttempts) * ResumeInterval * 2)
+	for err != nil && time.Now().Before(deadline) {
+		if registry.Lost {
+			return fmt.Errorf("lost connection to registry")
+		}
+		time.Sleep(100 * time.Millisecond)
+
+		if resumed := registry.GetConnection(); resumed != conn {
+			conn = resumed
+			err = utils.SendMessage(conn, chord)
+		}
+	}
+	return err
+}
+
+// Acts on the requests of the registry, until it has collected this node's TrafficSummary.
+// The registry may send a NodeRegistry more than once: to retry a setup that failed,
+// or with new routing tables when nodes were left out of the overlay.
+// After a task, completed or aborted, the node is ready for the next one.
+// After a resume, the registry repeats its last request, which must not be acted on twice.
+// Returned once the registry tells the node to exit
+var ErrShutdown = errors.New("the registry shut the overlay down")
+
+func HandleRegistryRequests(wg *sync.WaitGroup, node *types.NodeInfo, network *types.Network, registry *types.Registry) error {
+	var setup *pb.NodeRegistry
+	var summary *pb.TrafficSummary // of the last task, in case the registry asks for it again
+	var stop chan struct{}         // closed to stop the running task, nil while there is none
+	var finished chan struct{}     // closed once the running task's TaskFinished has been sent
+	accepting := false
+	receiving := false // datagrams, once the udp data plane opened the socket
+
+	logger.Info("Waiting for NodeRegistry packet from registry...")
+	for {
+		chord, err := ReceiveFromRegistry(registry)
+		if err != nil {
+			return err
+		}
+
+		switch msg := chord.GetMessage().(type) {
+		case *pb.MiniChord_NodeRegistry:
+			if stop != nil {
+				logger.Error("ignoring NodeRegistry, a task is running")
+				continue
+			}
+			if node.IsSetup && proto.Equal(setup, msg.NodeRegistry) {
+				// our answer may have been lost along with the old connection
+				if err := SendNodeRegistryResponse(node, network, registry, nil); err != nil {
+					return fmt.Errorf("error sending NodeRegistryResponse to registry: %s", err.Error())
+				}
+				continue
+			}
+			setup = msg.NodeRegistry
+
+			logger.Debugf("Ids: %v", setup.Ids)
+			setupErr := SetupNetwork(network, setup, node)
+			if setupErr != nil {
+				logger.Errorf("error setting up network: %s", setupErr.Error())
+			} else {
+				logger.Debug("RoutingTable: ")
+				for _, peer := range network.RoutingTable {
+					logger.Debugf("node: %d - address %s", peer.Id, peer.Address.ToString())
+				}
+
+				// peers are only admitted once the overlay's members are known
+				if !accepting {
+					wg.Add(1)
+					go HandleListener(wg, node, network)
+					accepting = true
+				}
+				if node.Datagrams != nil && !receiving {
+					go HandleDatagrams(node, network)
+					receiving = true
+				}
+				ConnectToNeighbours(node, network)
+			}
+
+			if err := SendNodeRegistryResponse(node, network, registry, setupErr); err != nil {
+				return fmt.Errorf("error sending NodeRegistryResponse to registry: %s", err.Error())
+			}
+		case *pb.MiniChord_InitiateTask:
+			if stop != nil {
+				select {
+				case <-finished:
+					// our TaskFinished may have been written to the connection that dropped
+					logger.Debug("repeating TaskFinished for repeated InitiateTask")
+					if err := SendToRegistry(registry, TaskFinishedMessage(node, registry)); err != nil {
+						return fmt.Errorf("error sending TaskFinished: %s", err.Error())
+					}
+				default:
+					logger.Debug("ignoring repeated InitiateTask from registry")
+				}
+				continue
+			}
+			stop = make(chan struct{})
+			finished = make(chan struct{})
+
+			// unless packets of other nodes already started the timeline
+			node.TimeLock.Lock()
+			if node.TaskStart.IsZero() {
+				node.TaskStart = time.Now()
+			}
+			node.TimeLock.Unlock()
+
+			logger.Infof("Initial packets: %d", msg.InitiateTask.Packets)
+			workload := msg.InitiateTask.GetWorkload()
+			if workload != types.UniformWorkload && workload != types.HotspotWorkload {
+				logger.Warningf("unknown workload %q, picking destinations uniformly", workload)
+			}
+
+			// create and add packets to sendChannel
+			go CreatePackets(node, network, msg.InitiateTask.Packets, workload, stop)
+
+			// Send task finished must be in a separate goroutine
+			// as the node must still handle connections after its sent
+			go SendTaskFinished(msg.InitiateTask.Packets, node, registry, stop, finished)
+			go SendProgress(node, network, registry, stop)
+		case *pb.MiniChord_RequestTrafficSummary, *pb.MiniChord_AbortTask:
+			if stop == nil {
+				// our summary may have been lost along with the old connection
+				if summary != nil {
+					logger.Debugf("repeating TrafficSummary for repeated %s", utils.GetMiniChordType(chord))
+					if err := SendToRegistry(registry, TrafficSummaryMessage(summary)); err != nil {
+						return fmt.Errorf("error sending TrafficSummary: %s", err.Error())
+					}
+				}
+				continue
+			}
+			close(stop)
+			stop = nil
+
+			if abort, ok := msg.(*pb.MiniChord_AbortTask); ok {
+				if abort.AbortTask.Drain {
+					left := DrainQueue(network)
+					EndRelayEpoch(network)
+					logger.Infof("Task aborted, sent the queued packets, %d left after %s", left, DrainTimeout)
+				} else {
+					EndRelayEpoch(network)
+					logger.Infof("Task aborted, discarded %d queued packets", DiscardQueue(network))
+				}
+
+				// packets other nodes sent before they stopped are still on their way,
+				// and belong to this task, not the next one
+				time.Sleep(AbortSettleTime)
+			}
+
+			summary = CollectTrafficSummary(node, registry)
+			logger.Infof("Sending TrafficSummary: %v", summary)
+			if err := SendToRegistry(registry, TrafficSummaryMessage(summary)); err != nil {
+				return fmt.Errorf("error sending TrafficSummary: %s", err.Error())
+			}
+
+			// not when the next task starts, as its first packets may arrive before its InitiateTask does
+			EndRelayEpoch(network)
+			ResetStats(node)
+			logger.Info("Ready for the next task")
+		case *pb.MiniChord_Shutdown:
+			if stop != nil {
+				close(stop)
+			}
+			return ErrShutdown
+		default:
+			logger.Errorf("unexpected %s from registry", utils.GetMiniChordType(chord))
+		}
 	}
-	return nr.NodeRegistry, nil
 }
 
-// Checks whether connecting to nodes in routing table succeeded
+// Checks whether setting up the network and connecting to nodes in routing table succeeded
 // then sends outcome in NodeRegistryResponse packet to registry
-func SendNodeRegistryResponse(node *types.NodeInfo, network *types.Network, registry *types.Registry) error {
-	success := true
+func SendNodeRegistryResponse(node *types.NodeInfo, network *types.Network, registry *types.Registry, setupErr error) error {
+	if setupErr != nil {
+		node.IsSetup = false
+		response := pb.NodeRegistryResponse{Result: 128, Info: fmt.Sprintf("I, node %v, address %s, failed to set up my network: %s", node.Id, node.Address.ToString(), setupErr.Error())}
+		chord := pb.MiniChord{Message: &pb.MiniChord_NodeRegistryResponse{NodeRegistryResponse: &response}}
+
+		return SendToRegistry(registry, &chord)
+	}
+
+	unreachable := []int32{}
 	for _, peer := range network.RoutingTable {
-		if peer.Connection == nil {
+		if network.DataPlane == types.DatagramDataPlane {
+			if peer.UDPAddress == nil {
+				logger.Errorf("datagram address of peer %d seems to be nil", peer.Id)
+				unreachable = append(unreachable, peer.Id)
+			}
+		} else if peer.Connection == nil {
 			logger.Errorf("connection to peer %d seems to be nil", peer.Id)
-			success = false
+			unreachable = append(unreachable, peer.Id)
 		}
 	}
+	success := len(unreachable) == 0
+	node.IsSetup = success
 
 	if success {
 		response := pb.NodeRegistryResponse{Result: uint32(node.Id), Info: fmt.Sprintf("I, node %v, address %s, hereby confirm that I've successfully connected to all my neigbours...", node.Id, node.Address.ToString())}
 		chord := pb.MiniChord{Message: &pb.MiniChord_NodeRegistryResponse{NodeRegistryResponse: &response}}
 
-		return utils.SendMessage(registry.Connection, &chord)
+		return SendToRegistry(registry, &chord)
 	} else {
 		// message nodes can't send -1 below, even though the assignment description specifies that it must do that on failure.
 		// as nodes can only have valid ids between 0 - 127, a failure Id can be 128.
-		response := pb.NodeRegistryResponse{Result: 128, Info: fmt.Sprintf("I, node %v, address %s, hereby deny that I've successfully connected to all my neigbours...", node.Id, node.Address.ToString())}
+		response := pb.NodeRegistryResponse{Result: 128, Info: fmt.Sprintf("I, node %v, address %s, hereby deny that I've successfully connected to all my neigbours...", node.Id, node.Address.ToString()), Unreachable: unreachable}
 		chord := pb.MiniChord{Message: &pb.MiniChord_NodeRegistryResponse{NodeRegistryResponse: &response}}
 
-		return utils.SendMessage(registry.Connection, &chord)
+		return SendToRegistry(registry, &chord)
 	}
 }
 
-// Get the InitiateTask MiniChord Message from the registry
-func GetInitiateTasks(node *types.NodeInfo, registry *types.Registry) (uint32, error) {
-	chord, err := utils.ReceiveMessage(registry.Connection)
-	if err != nil {
-		return 0, err
-	}
-
-	nr, ok := chord.GetMessage().(*pb.MiniChord_InitiateTask)
-	if !ok {
-		return 0, fmt.Errorf("error when parsing registrationResponse packet")
-	}
-
-	logger.Infof("Initial packets: %d", nr.InitiateTask.Packets)
-
-	// no new connections can be made to this node
-	node.Listener.Close()
-	node.Listening = false
-
-	return nr.InitiateTask.Packets, nil
-}
-
 // Waits for all messages to have been sent
-// and then sends TaskFinished message to registry
-func SendTaskFinishedAndTrafficSummary(packets uint32, node *types.NodeInfo, network *types.Network, registry *types.Registry) {
-	for packets > node.Stats.Sent {
-		time.Sleep(100 * time.Millisecond)
+// and then sends TaskFinished message to registry, unless the task is stopped first.
+// Closes finished once it has been sent.
+func SendTaskFinished(packets uint32, node *types.NodeInfo, registry *types.Registry, stop chan struct{}, finished chan struct{}) {
+	for packets > StatsSnapshot(node).Sent {
+		select {
+		case <-stop:
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
 	}
 
 	logger.Info("All packets sent, sending TaskFinished")
 
-	taskFinished := &pb.TaskFinished{Id: node.Id, Address: node.Address.ToString()}
-	chord := &pb.MiniChord{Message: &pb.MiniChord_TaskFinished{TaskFinished: taskFinished}}
-
-	err := utils.SendMessage(registry.Connection, chord)
+	err := SendToRegistry(registry, TaskFinishedMessage(node, registry))
 	if err != nil {
 		logger.Errorf("error sending TaskFinished to registry: %s", err.Error())
 		os.Exit(1)
 	}
+	close(finished)
+}
 
-	// Receive RequestTrafficSummary
-	response, err := utils.ReceiveMessage(registry.Connection)
-	if err != nil {
-		logger.Errorf("error when receiving RequestTrafficSummary: %s", err.Error())
-		os.Exit(1)
-	}
+func TaskFinishedMessage(node *types.NodeInfo, registry *types.Registry) *pb.MiniChord {
+	taskFinished := &pb.TaskFinished{Id: node.Id, Address: node.Address.ToString(), Token: registry.Token}
+	return &pb.MiniChord{Message: &pb.MiniChord_TaskFinished{TaskFinished: taskFinished}}
+}
 
-	// Close packet channel, node won't relay any more messages
-	close(network.SendChannel)
-	node.HasClosed = true
+// How often a node reports its progress while a task runs
+var ProgressInterval = 1 * time.Second
 
-	switch response.Message.(type) {
-	case *pb.MiniChord_RequestTrafficSummary:
-		// Send TrafficSummary
-		err := SendTrafficSummary(registry, node)
-		if err != nil {
-			logger.Errorf("error sending TrafficSummary: %s", err.Error())
-			os.Exit(1)
+// Reports the node's counters to the registry every ProgressInterval, until the task is stopped
+func SendProgress(node *types.NodeInfo, network *types.Network, registry *types.Registry, stop chan struct{}) {
+	ticker := time.NewTicker(ProgressInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		stats := StatsSnapshot(node)
+		progress := &pb.TaskProgress{Id: node.Id, Sent: stats.Sent, Relayed: stats.Relayed, Received: stats.Received, QueueDepth: uint32(len(network.SendChannel)), Token: registry.Token}
+		chord := &pb.MiniChord{Message: &pb.MiniChord_TaskProgress{TaskProgress: progress}}
+
+		// the next report makes up for a lost one
+		if err := SendToRegistry(registry, chord); err != nil {
+			logger.Warningf("error sending TaskProgress to registry: %s", err.Error())
 		}
-	default:
-		logger.Errorf("response not of type RequestTrafficSummary: %v", response.Message)
 	}
 }
 
-func SendTrafficSummary(registry *types.Registry, node *types.NodeInfo) error {
+// Takes the node's counters of the current task into a TrafficSummary
+func CollectTrafficSummary(node *types.NodeInfo, registry *types.Registry) *pb.TrafficSummary {
+	trafficSummary := StatsSnapshot(node)
+	trafficSummary.Id = node.Id
+	trafficSummary.Token = registry.Token
+
+	node.LinkLock.Lock()
+	for _, link := range node.Links {
+		trafficSummary.Links = append(trafficSummary.Links, &pb.LinkStats{
+			Peer:               link.Peer,
+			DatagramsSent:      link.DatagramsSent,
+			DatagramsReceived:  link.DatagramsReceived,
+			DatagramsReordered: link.DatagramsReordered,
+			PacketsSent:        link.PacketsSent,
+			BytesSent:          link.BytesSent,
+			PacketsReceived:    link.PacketsReceived,
+			BytesReceived:      link.BytesReceived,
+			SendErrors:         link.SendErrors,
+			MaxQueueDepth:      link.MaxQueueDepth,
+		})
+	}
+	node.LinkLock.Unlock()
+
+	node.SendLock.Lock()
+	for destination, packets := range node.SentTo {
+		trafficSummary.SentTo = append(trafficSummary.SentTo, &pb.FlowCount{Node: destination, Packets: packets})
+	}
+	node.SendLock.Unlock()
 
-	trafficSummary := &pb.TrafficSummary{Id: node.Id, Sent: node.Stats.Sent, Relayed: node.Stats.Relayed, Received: node.Stats.Received, TotalSent: node.Stats.TotalSent, TotalReceived: node.Stats.TotalReceived}
+	node.RecvLock.Lock()
+	for source, packets := range node.RecvFrom {
+		trafficSummary.ReceivedFrom = append(trafficSummary.ReceivedFrom, &pb.FlowCount{Node: source, Packets: packets})
+	}
+	node.RecvLock.Unlock()
 
-	chord := &pb.MiniChord{Message: &pb.MiniChord_ReportTrafficSummary{ReportTrafficSummary: trafficSummary}}
+	node.TimeLock.Lock()
+	for _, second := range node.Timeline {
+		trafficSummary.Timeline = append(trafficSummary.Timeline, &pb.TimelineSecond{Sent: second.Sent, Relayed: second.Relayed, Received: second.Received})
+	}
+	node.TimeLock.Unlock()
 
-	logger.Infof("Sending TrafficSummary: %v", trafficSummary)
+	return trafficSummary
+}
 
-	return utils.SendMessage(registry.Connection, chord)
+func TrafficSummaryMessage(trafficSummary *pb.TrafficSummary) *pb.MiniChord {
+	return &pb.MiniChord{Message: &pb.MiniChord_ReportTrafficSummary{ReportTrafficSummary: trafficSummary}}
 }
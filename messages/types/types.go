@@ -1,11 +1,14 @@
 package types
 
 import (
+	"crypto/ed25519"
 	"net"
 	"strconv"
 	"sync"
+	"time"
 
 	pb "github.com/lsig/OverlayNetwork/pb"
+	"github.com/lsig/OverlayNetwork/transport"
 )
 
 type Address struct {
@@ -13,35 +16,110 @@ type Address struct {
 	Port uint16
 }
 
+// Formats the address as <host>:<port>, with IPv6 hosts in brackets
 func (a Address) ToString() string {
-	return a.Host.String() + ":" + strconv.Itoa(int(a.Port))
+	return net.JoinHostPort(a.Host.String(), strconv.Itoa(int(a.Port)))
 }
 
 type Registry struct {
-	Address    Address
-	Connection net.Conn
+	Address         Address
+	Connection      net.Conn
+	SharedKey       []byte // pre-shared key proving this node may register, if the registry requires one
+	Token           []byte // session token issued at registration, sent along with every control message
+	Messages        chan *pb.MiniChord
+	Deregistrations chan *pb.DeregistrationResponse
+	Lost            bool       // set once the session can't be resumed anymore
+	Lock            sync.Mutex // guards Connection, which is replaced when the session is resumed
+	SendLock        sync.Mutex // keeps messages sent from different goroutines from interleaving
+}
+
+func (r *Registry) GetConnection() net.Conn {
+	r.Lock.Lock()
+	defer r.Lock.Unlock()
+	return r.Connection
 }
 
 type NodeInfo struct {
-	Id        int32
-	Address   Address
-	Listener  net.Listener
-	Listening bool
-	IsSetup   bool
-	HasClosed bool
-	Stats     pb.TrafficSummary
-	RecvLock  sync.Mutex
-	SendLock  sync.Mutex
+	Id         int32
+	Address    Address // advertised to the registry and other nodes
+	BindHost   string  // host the listener and datagram socket are bound to
+	Transport  transport.Transport
+	SigningKey ed25519.PrivateKey // nil if the node doesn't sign its packets
+	Listener   net.Listener
+	Datagrams  *net.UDPConn // bound to the listener's port number once the registry picks the udp data plane
+	Listening  bool
+	IsSetup    bool
+	HasClosed  bool
+	Stats      pb.TrafficSummary
+	Links      map[int32]*Link
+	SentTo     map[int32]uint32        // packets created, per destination, guarded by SendLock
+	RecvFrom   map[int32]uint32        // packets arrived, per source, guarded by RecvLock
+	Sequences  map[int32]uint64        // last sequence number signed, per destination, guarded by SendLock
+	Replays    map[int32]*ReplayWindow // sequence numbers that arrived, per signing source, guarded by RecvLock
+	Timeline   []Second                // counters of the current task, one per second
+	TaskStart  time.Time               // start of the timeline, zero until the task shows up in it
+	RecvLock   sync.Mutex
+	SendLock   sync.Mutex
+	LinkLock   sync.Mutex
+	TimeLock   sync.Mutex // guards Timeline and TaskStart
+}
+
+// Packets a node sent, relayed and received during a single second of a task
+type Second struct {
+	Sent     uint32
+	Relayed  uint32
+	Received uint32
+}
+
+// Counters for the link between this node and a peer,
+// in both directions
+type Link struct {
+	Peer               int32
+	PacketsSent        uint32
+	BytesSent          uint64
+	PacketsReceived    uint32
+	BytesReceived      uint64
+	SendErrors         uint32
+	MaxQueueDepth      uint32 // deepest the send channel was when a packet left on this link
+	DatagramsSent      uint32
+	DatagramsReceived  uint32
+	DatagramsReordered uint32
+	HighestSequence    uint32
+}
+
+// The sequence numbers of the signed packets a source sent this node, kept across tasks.
+// Packets overtake each other on the way, so every number in a window below the highest is remembered.
+type ReplayWindow struct {
+	Highest uint64
+	Seen    []uint64 // bit s%size is set if sequence number s arrived
 }
 
 type ExternalNode struct {
 	Id         int32
 	Address    Address
 	Connection net.Conn
+	UDPAddress *net.UDPAddr
 }
 
+const (
+	StreamDataPlane   = ""
+	DatagramDataPlane = "udp"
+)
+
+// How nodes pick the destinations of the packets they create
+const (
+	UniformWorkload = ""        // every other node is equally likely
+	HotspotWorkload = "hotspot" // half the packets go to the node with the lowest id
+)
+
 type Network struct {
 	Nodes        []int32
 	RoutingTable []*ExternalNode
 	SendChannel  chan *pb.NodeData
+	DataPlane    string
+	PublicKeys   map[int32]ed25519.PublicKey // keys of the nodes that sign their packets
+	Members      map[int32]Address           // addresses of all other nodes, for admitting peers
+	Identities   map[int32]string            // certificate names the other nodes registered with, empty without tls
+	Relays       chan struct{}               // closed once the packets waiting to be relayed belong to a stopped task
+	RelayLock    sync.Mutex                  // guards Relays
 }
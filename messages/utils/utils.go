@@ -1,71 +1,120 @@
 package utils
 
 import (
+	"crypto/ed25519"
 	"encoding/binary"
 	"fmt"
 	"io"
-	"math"
 	"math/rand"
 	"net"
+	"slices"
 	"strconv"
-	"strings"
+	"sync"
 
 	"github.com/lsig/OverlayNetwork/logger"
 	"github.com/lsig/OverlayNetwork/messages/types"
 	pb "github.com/lsig/OverlayNetwork/pb"
+	"github.com/lsig/OverlayNetwork/transport"
 	"google.golang.org/protobuf/proto"
 )
 
+// Parses <host>:<port>, where host is an IPv4 address, an IPv6 address in brackets such as [::1], or a host name
 func GetAddressFromString(addrString string) (*types.Address, error) {
-	addressInfo := strings.Split(addrString, ":")
-	if len(addressInfo) != 2 {
-		return nil, fmt.Errorf("semicolon missing in address string: %s", addrString)
+	host, portString, err := net.SplitHostPort(addrString)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %s: %s", addrString, err.Error())
 	}
 
-	if addressInfo[0] == "localhost" {
-		addressInfo[0] = "127.0.0.1"
+	port, err := strconv.Atoi(portString)
+	if err != nil || port < 1 || port > 65535 {
+		return nil, fmt.Errorf("invalid port in address %s", addrString)
 	}
 
-	address := net.ParseIP(addressInfo[0])
-	port, err := strconv.Atoi(addressInfo[1])
-
-	if address == nil || err != nil || port <= 1024 || port >= 65536 {
-		return nil, fmt.Errorf("invalid address or port")
+	address, err := ResolveHost(host)
+	if err != nil {
+		return nil, err
 	}
 
 	return &types.Address{Host: address, Port: uint16(port)}, nil
 }
 
+// Returns the IP address of host, looking it up if it's a host name.
+// Names with both IPv4 and IPv6 addresses resolve to an IPv4 one, as listening on such a name does.
+func ResolveHost(host string) (net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip, nil
+	}
+	if host == "" {
+		return nil, fmt.Errorf("missing host")
+	}
+
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving %s: %s", host, err.Error())
+	}
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			return ip, nil
+		}
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("%s has no addresses", host)
+	}
+	return ips[0], nil
+}
+
 func GetRegistryFromProgramArgs(args []string) (*types.Registry, error) {
-	usageError := fmt.Errorf("usage: go run messages/messages.go <registry-host>:<registry-port>")
-	if len(args) != 2 {
+	usageError := fmt.Errorf("usage: overlay node [flags] <registry-host>:<registry-port>")
+	if len(args) != 1 {
 		return nil, usageError
 	}
 
-	address, err := GetAddressFromString(args[1])
+	address, err := GetAddressFromString(args[0])
 	if err != nil {
-		return nil, usageError
+		return nil, fmt.Errorf("%s\n%s", err.Error(), usageError.Error())
 	}
 
-	registry := types.Registry{Address: *address}
+	registry := types.Registry{Address: *address, Messages: make(chan *pb.MiniChord, 16), Deregistrations: make(chan *pb.DeregistrationResponse, 1)}
 
 	return &registry, nil
 }
 
-func GenerateRandomPort() int {
-	randomPort := -1
+// Source of the destinations and payloads of the packets a node creates.
+// Nodes running in the same process, such as over the pipe transport, share it.
+var random = rand.New(newLockedSource(rand.Int63()))
 
-	for randomPort < 0 {
-		randomPort = rand.Intn(int(math.Pow(2, 16))-1024) + 1024 // first ca 1024 ports are restricted for OS
-		conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", randomPort))
-		if err == nil {
-			fmt.Printf("server live on: %v\n", conn.RemoteAddr().String())
-			randomPort = -1
-			conn.Close()
-		}
-	}
+// Makes the destinations and payloads of a node the same for every task it runs after being seeded with seed,
+// given it has the same id and peers
+func Seed(seed int64) {
+	random = rand.New(newLockedSource(seed))
+}
+
+// A random source goroutines can share, which those of math/rand aren't
+type lockedSource struct {
+	lock   sync.Mutex
+	source rand.Source64
+}
+
+func newLockedSource(seed int64) *lockedSource {
+	return &lockedSource{source: rand.NewSource(seed).(rand.Source64)}
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.source.Int63()
+}
 
-	return randomPort
+func (s *lockedSource) Uint64() uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.source.Uint64()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.source.Seed(seed)
 }
 
 const I64SIZE int = 8
@@ -121,6 +170,39 @@ func SendMessage(conn net.Conn, message *pb.MiniChord) error {
 	return nil
 }
 
+// Large enough for any datagram that fits into a single udp packet
+const MaxDatagramSize int = 65535
+
+// Sends a NodeDatagram as a single udp packet, without the length prefix used on streams
+func SendDatagram(conn *net.UDPConn, address *net.UDPAddr, datagram *pb.NodeDatagram) error {
+	data, err := proto.Marshal(datagram)
+	if err != nil {
+		return fmt.Errorf("failed to marshal datagram %w", err)
+	}
+
+	if _, err := conn.WriteToUDP(data, address); err != nil {
+		return fmt.Errorf("error sending datagram %w", err)
+	}
+
+	return nil
+}
+
+// Reads the next NodeDatagram from the socket into buffer and unmarshals it,
+// returning the address it was sent from as well
+func ReceiveDatagram(conn *net.UDPConn, buffer []byte) (*pb.NodeDatagram, *net.UDPAddr, error) {
+	n, from, err := conn.ReadFromUDP(buffer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	datagram := &pb.NodeDatagram{}
+	if err := proto.Unmarshal(buffer[:n], datagram); err != nil {
+		return nil, from, err
+	}
+
+	return datagram, from, nil
+}
+
 func GetMiniChordType(msg *pb.MiniChord) string {
 	switch msg.Message.(type) {
 	case *pb.MiniChord_Registration:
@@ -139,8 +221,20 @@ func GetMiniChordType(msg *pb.MiniChord) string {
 		return "NodeData"
 	case *pb.MiniChord_TaskFinished:
 		return "TaskFinished"
+	case *pb.MiniChord_RequestTrafficSummary:
+		return "RequestTrafficSummary"
 	case *pb.MiniChord_ReportTrafficSummary:
 		return "ReportTrafficSummary"
+	case *pb.MiniChord_AbortTask:
+		return "AbortTask"
+	case *pb.MiniChord_TaskProgress:
+		return "TaskProgress"
+	case *pb.MiniChord_Shutdown:
+		return "Shutdown"
+	case *pb.MiniChord_PeerHandshake:
+		return "PeerHandshake"
+	case *pb.MiniChord_PeerHandshakeResponse:
+		return "PeerHandshakeResponse"
 	default:
 		logger.Warning("unknown minichord message encountered...")
 		return "Unknown"
@@ -148,10 +242,24 @@ func GetMiniChordType(msg *pb.MiniChord) string {
 }
 
 func GetRandomNode(nodes []int32) int32 {
-	index := rand.Intn(len(nodes))
+	index := random.Intn(len(nodes))
 	return nodes[index]
 }
 
+// Share of its packets a node sends to the hotspot under the hotspot workload
+const HotspotShare = 0.5
+
+// Picks the destination of a packet according to the workload of the task.
+// The hotspot is the node with the lowest id, which sends its own packets uniformly.
+func PickDestination(nodes []int32, self int32, workload string) int32 {
+	if workload == types.HotspotWorkload {
+		if hotspot := slices.Min(nodes); hotspot < self && random.Float64() < HotspotShare {
+			return hotspot
+		}
+	}
+	return GetRandomNode(nodes)
+}
+
 func FindBestNeighbour(routingTable []*types.ExternalNode, packet *pb.NodeData) *types.ExternalNode {
 	// Welcome to the routing algorithm...
 	bestIndex := -1
@@ -183,6 +291,108 @@ func NodeDataPacketIsMalformed(nodeData *pb.NodeData, node *types.NodeInfo) (boo
 	return false, ""
 }
 
+// The part of a NodeData packet covered by the source's signature.
+// Hops and Trace change on every relay, so they are left out.
+func nodeDataDigest(nodeData *pb.NodeData) []byte {
+	digest := make([]byte, 20)
+	binary.BigEndian.PutUint32(digest[0:4], uint32(nodeData.Source))
+	binary.BigEndian.PutUint32(digest[4:8], uint32(nodeData.Destination))
+	binary.BigEndian.PutUint32(digest[8:12], uint32(nodeData.Payload))
+	binary.BigEndian.PutUint64(digest[12:20], nodeData.Sequence)
+	return digest
+}
+
+func SignNodeData(key ed25519.PrivateKey, nodeData *pb.NodeData) {
+	nodeData.Signature = ed25519.Sign(key, nodeDataDigest(nodeData))
+}
+
+// Checks a packet against the key its source registered.
+// Packets from sources without a key can't be checked, and are accepted as they are.
+func NodeDataSignatureIsValid(nodeData *pb.NodeData, publicKeys map[int32]ed25519.PublicKey) bool {
+	key, ok := publicKeys[nodeData.Source]
+	if !ok {
+		return true
+	}
+	return len(nodeData.Signature) == ed25519.SignatureSize && ed25519.Verify(key, nodeDataDigest(nodeData), nodeData.Signature)
+}
+
+// How many sequence numbers below the highest one of a source are remembered.
+// Older packets are taken for replayed ones, so it bounds how far a packet may be overtaken.
+const ReplayWindowSize = 1 << 16
+
+// Checks that a signed packet didn't arrive before, and remembers it did now.
+// Packets from sources without a key carry no sequence number, and are never taken for replayed ones.
+// node.RecvLock must be held by the caller
+func NodeDataIsReplayed(nodeData *pb.NodeData, node *types.NodeInfo, publicKeys map[int32]ed25519.PublicKey) bool {
+	if _, ok := publicKeys[nodeData.Source]; !ok {
+		return false
+	}
+	window, ok := node.Replays[nodeData.Source]
+	if !ok {
+		window = &types.ReplayWindow{Seen: make([]uint64, ReplayWindowSize/64)}
+		node.Replays[nodeData.Source] = window
+	}
+
+	sequence := nodeData.Sequence
+	switch {
+	case sequence == 0:
+		return true
+	case sequence > window.Highest:
+		if sequence-window.Highest >= ReplayWindowSize {
+			clear(window.Seen)
+		} else {
+			// the numbers skipped on the way up haven't arrived yet
+			for s := window.Highest + 1; s < sequence; s++ {
+				window.Seen[s%ReplayWindowSize/64] &^= 1 << (s % 64)
+			}
+		}
+		window.Highest = sequence
+	case window.Highest-sequence >= ReplayWindowSize:
+		return true
+	case window.Seen[sequence%ReplayWindowSize/64]&(1<<(sequence%64)) != 0:
+		return true
+	}
+	window.Seen[sequence%ReplayWindowSize/64] |= 1 << (sequence % 64)
+	return false
+}
+
+// Only nodes that are part of the overlay may connect to this node,
+// and only from the host they registered with
+func PeerIsAdmitted(id int32, remoteAddr string, network *types.Network) (bool, string) {
+	member, ok := network.Members[id]
+	if !ok {
+		return false, fmt.Sprintf("node %d is not part of the overlay", id)
+	}
+
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return false, fmt.Sprintf("can't tell the host of %s", remoteAddr)
+	}
+
+	if !member.Host.Equal(net.ParseIP(host)) {
+		return false, fmt.Sprintf("node %d registered from %s, but connected from %s", id, member.Host.String(), host)
+	}
+	return true, ""
+}
+
+// With tls, a peer must present the certificate it registered with.
+// Certificates are usually issued for hosts the nodes share, so their names are what tells the nodes apart.
+func PeerCertificateMatches(id int32, conn net.Conn, network *types.Network) (bool, string) {
+	name := network.Identities[id]
+	if name == "" {
+		return true, ""
+	}
+
+	certificate := transport.PeerCertificate(conn)
+	if certificate == nil {
+		return false, fmt.Sprintf("node %d registered as %s, but presented no certificate", id, name)
+	}
+	if certificate.Subject.CommonName != name {
+		return false, fmt.Sprintf("node %d registered as %s, but presented the certificate of %s", id, name, certificate.Subject.CommonName)
+	}
+	return true, ""
+}
+
 func GeneratePayload() int32 {
 	var min int64 = -2147483648
 	var max int64 = 2147483647
@@ -191,7 +401,7 @@ func GeneratePayload() int32 {
 	// so we must subtract it to get a range from 0 - 4.294.967.295
 	// Also, the generator function finds a number with an half-open interval,
 	// so we must add one to the input to ensure that 2147483647 can be generated
-	payload := rand.Int63n(max - min + 1)
+	payload := random.Int63n(max - min + 1)
 
 	// subtract by the minimum amount to get a range from -2147483648 - 2147483647
 	payload += min
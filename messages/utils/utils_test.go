@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/lsig/OverlayNetwork/messages/types"
+	pb "github.com/lsig/OverlayNetwork/pb"
+)
+
+func TestGetAddressFromString(t *testing.T) {
+	tests := []struct {
+		address string
+		host    net.IP
+		port    uint16
+		fails   bool
+	}{
+		{address: "127.0.0.1:8080", host: net.IPv4(127, 0, 0, 1), port: 8080},
+		{address: "10.0.0.2:1", host: net.IPv4(10, 0, 0, 2), port: 1},
+		{address: "[::1]:65535", host: net.IPv6loopback, port: 65535},
+		{address: "localhost:8080", host: net.IPv4(127, 0, 0, 1), port: 8080},
+		{address: "::1:8080", fails: true},
+		{address: "127.0.0.1", fails: true},
+		{address: "127.0.0.1:0", fails: true},
+		{address: "127.0.0.1:65536", fails: true},
+		{address: "127.0.0.1:port", fails: true},
+		{address: ":8080", fails: true},
+		{address: "", fails: true},
+	}
+
+	for _, test := range tests {
+		address, err := GetAddressFromString(test.address)
+		if test.fails {
+			if err == nil {
+				t.Errorf("GetAddressFromString(%q) = %v, want an error", test.address, address)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("GetAddressFromString(%q) failed: %v", test.address, err)
+			continue
+		}
+		if !address.Host.Equal(test.host) || address.Port != test.port {
+			t.Errorf("GetAddressFromString(%q) = %s, want %s", test.address, address.ToString(), net.JoinHostPort(test.host.String(), strconv.Itoa(int(test.port))))
+		}
+	}
+}
+
+func TestResolveHost(t *testing.T) {
+	tests := []struct {
+		host  string
+		ip    net.IP
+		fails bool
+	}{
+		{host: "127.0.0.2", ip: net.IPv4(127, 0, 0, 2)},
+		{host: "::1", ip: net.IPv6loopback},
+		{host: "::ffff:127.0.0.1", ip: net.IPv4(127, 0, 0, 1)},
+		{host: "localhost", ip: net.IPv4(127, 0, 0, 1)},
+		{host: "", fails: true},
+		{host: "no-such-host.invalid", fails: true},
+	}
+
+	for _, test := range tests {
+		ip, err := ResolveHost(test.host)
+		if test.fails {
+			if err == nil {
+				t.Errorf("ResolveHost(%q) = %s, want an error", test.host, ip)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ResolveHost(%q) failed: %v", test.host, err)
+			continue
+		}
+		if !ip.Equal(test.ip) {
+			t.Errorf("ResolveHost(%q) = %s, want %s", test.host, ip, test.ip)
+		}
+	}
+}
+
+func TestNodeDataIsReplayed(t *testing.T) {
+	node := &types.NodeInfo{Replays: map[int32]*types.ReplayWindow{}}
+	publicKeys := map[int32]ed25519.PublicKey{1: make(ed25519.PublicKey, ed25519.PublicKeySize)}
+
+	// every step depends on the ones before it
+	tests := []struct {
+		source   int32
+		sequence uint64
+		replayed bool
+	}{
+		{source: 1, sequence: 1},
+		{source: 1, sequence: 3},
+		{source: 1, sequence: 2},
+		{source: 1, sequence: 2, replayed: true},
+		{source: 1, sequence: 3, replayed: true},
+		{source: 1, sequence: 0, replayed: true},
+		{source: 1, sequence: 70000},
+		{source: 1, sequence: 69999},
+		{source: 1, sequence: 69999, replayed: true},
+		{source: 1, sequence: 70000 - ReplayWindowSize + 1},
+		{source: 1, sequence: 70000 - ReplayWindowSize, replayed: true},
+		{source: 1, sequence: 4, replayed: true},
+		{source: 1, sequence: 300000},
+		{source: 1, sequence: 70000, replayed: true},
+		// sources that don't sign can't be told apart from replays
+		{source: 2, sequence: 0},
+		{source: 2, sequence: 0},
+	}
+
+	for i, test := range tests {
+		nodeData := &pb.NodeData{Source: test.source, Sequence: test.sequence}
+		if replayed := NodeDataIsReplayed(nodeData, node, publicKeys); replayed != test.replayed {
+			t.Errorf("step %d: NodeDataIsReplayed(source %d, sequence %d) = %v, want %v", i, test.source, test.sequence, replayed, test.replayed)
+		}
+	}
+}
+
+func TestPeerCertificateMatches(t *testing.T) {
+	conn, other := net.Pipe()
+	defer conn.Close()
+	defer other.Close()
+
+	network := &types.Network{Identities: map[int32]string{1: "", 2: "node-2"}}
+	tests := []struct {
+		id      int32
+		matches bool
+	}{
+		{id: 1, matches: true},
+		{id: 2, matches: false},
+		{id: 3, matches: true},
+	}
+
+	for _, test := range tests {
+		if matches, reason := PeerCertificateMatches(test.id, conn, network); matches != test.matches {
+			t.Errorf("PeerCertificateMatches(%d) = %v (%s), want %v", test.id, matches, reason, test.matches)
+		}
+	}
+}
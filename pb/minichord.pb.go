@@ -25,7 +25,9 @@ type Registration struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Address string `protobuf:"bytes,1,opt,name=Address,proto3" json:"Address,omitempty"` // Address of the peer that registers, must be acceptable by func Dial
+	Address   string `protobuf:"bytes,1,opt,name=Address,proto3" json:"Address,omitempty"`     // Address of the peer that registers, must be acceptable by func Dial
+	PublicKey []byte `protobuf:"bytes,2,opt,name=PublicKey,proto3" json:"PublicKey,omitempty"` // ed25519 key the node signs its NodeData with, empty if it doesn't sign
+	Token     []byte `protobuf:"bytes,3,opt,name=Token,proto3" json:"Token,omitempty"`         // session token of an earlier registration, to resume it after the connection dropped
 }
 
 func (x *Registration) Reset() {
@@ -67,6 +69,20 @@ func (x *Registration) GetAddress() string {
 	return ""
 }
 
+func (x *Registration) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+func (x *Registration) GetToken() []byte {
+	if x != nil {
+		return x.Token
+	}
+	return nil
+}
+
 type RegistrationResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -74,6 +90,7 @@ type RegistrationResponse struct {
 
 	Result int32  `protobuf:"fixed32,2,opt,name=Result,proto3" json:"Result,omitempty"`
 	Info   string `protobuf:"bytes,3,opt,name=Info,proto3" json:"Info,omitempty"`
+	Token  []byte `protobuf:"bytes,4,opt,name=Token,proto3" json:"Token,omitempty"` // session token, for resuming the registration and for control messages when the registry uses a pre-shared key
 }
 
 func (x *RegistrationResponse) Reset() {
@@ -122,17 +139,24 @@ func (x *RegistrationResponse) GetInfo() string {
 	return ""
 }
 
-type Deregistration struct {
+func (x *RegistrationResponse) GetToken() []byte {
+	if x != nil {
+		return x.Token
+	}
+	return nil
+}
+
+// Sent by the registry in reply to a Registration when it uses a pre-shared key
+type AuthChallenge struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Id      int32  `protobuf:"fixed32,2,opt,name=Id,proto3" json:"Id,omitempty"`
-	Address string `protobuf:"bytes,1,opt,name=Address,proto3" json:"Address,omitempty"`
+	Nonce []byte `protobuf:"bytes,1,opt,name=Nonce,proto3" json:"Nonce,omitempty"`
 }
 
-func (x *Deregistration) Reset() {
-	*x = Deregistration{}
+func (x *AuthChallenge) Reset() {
+	*x = AuthChallenge{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_minichord_proto_msgTypes[2]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -140,13 +164,13 @@ func (x *Deregistration) Reset() {
 	}
 }
 
-func (x *Deregistration) String() string {
+func (x *AuthChallenge) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Deregistration) ProtoMessage() {}
+func (*AuthChallenge) ProtoMessage() {}
 
-func (x *Deregistration) ProtoReflect() protoreflect.Message {
+func (x *AuthChallenge) ProtoReflect() protoreflect.Message {
 	mi := &file_minichord_proto_msgTypes[2]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -158,36 +182,28 @@ func (x *Deregistration) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Deregistration.ProtoReflect.Descriptor instead.
-func (*Deregistration) Descriptor() ([]byte, []int) {
+// Deprecated: Use AuthChallenge.ProtoReflect.Descriptor instead.
+func (*AuthChallenge) Descriptor() ([]byte, []int) {
 	return file_minichord_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *Deregistration) GetId() int32 {
-	if x != nil {
-		return x.Id
-	}
-	return 0
-}
-
-func (x *Deregistration) GetAddress() string {
+func (x *AuthChallenge) GetNonce() []byte {
 	if x != nil {
-		return x.Address
+		return x.Nonce
 	}
-	return ""
+	return nil
 }
 
-type DeregistrationResponse struct {
+type AuthResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Result int32  `protobuf:"fixed32,2,opt,name=Result,proto3" json:"Result,omitempty"`
-	Info   string `protobuf:"bytes,3,opt,name=Info,proto3" json:"Info,omitempty"`
+	Mac []byte `protobuf:"bytes,1,opt,name=Mac,proto3" json:"Mac,omitempty"` // HMAC-SHA256 over the nonce and the registration address, keyed with the pre-shared key
 }
 
-func (x *DeregistrationResponse) Reset() {
-	*x = DeregistrationResponse{}
+func (x *AuthResponse) Reset() {
+	*x = AuthResponse{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_minichord_proto_msgTypes[3]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -195,13 +211,13 @@ func (x *DeregistrationResponse) Reset() {
 	}
 }
 
-func (x *DeregistrationResponse) String() string {
+func (x *AuthResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeregistrationResponse) ProtoMessage() {}
+func (*AuthResponse) ProtoMessage() {}
 
-func (x *DeregistrationResponse) ProtoReflect() protoreflect.Message {
+func (x *AuthResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_minichord_proto_msgTypes[3]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -213,38 +229,30 @@ func (x *DeregistrationResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeregistrationResponse.ProtoReflect.Descriptor instead.
-func (*DeregistrationResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use AuthResponse.ProtoReflect.Descriptor instead.
+func (*AuthResponse) Descriptor() ([]byte, []int) {
 	return file_minichord_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *DeregistrationResponse) GetResult() int32 {
-	if x != nil {
-		return x.Result
-	}
-	return 0
-}
-
-func (x *DeregistrationResponse) GetInfo() string {
+func (x *AuthResponse) GetMac() []byte {
 	if x != nil {
-		return x.Info
+		return x.Mac
 	}
-	return ""
+	return nil
 }
 
-type NodeRegistry struct {
+type Deregistration struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	NR    uint32            `protobuf:"fixed32,4,opt,name=NR,proto3" json:"NR,omitempty"`
-	Peers []*Deregistration `protobuf:"bytes,5,rep,name=Peers,proto3" json:"Peers,omitempty"` // Pair of Id and Address
-	NoIds uint32            `protobuf:"fixed32,7,opt,name=NoIds,proto3" json:"NoIds,omitempty"`
-	Ids   []int32           `protobuf:"fixed32,6,rep,packed,name=Ids,proto3" json:"Ids,omitempty"`
+	Id      int32  `protobuf:"fixed32,2,opt,name=Id,proto3" json:"Id,omitempty"`
+	Address string `protobuf:"bytes,1,opt,name=Address,proto3" json:"Address,omitempty"`
+	Token   []byte `protobuf:"bytes,3,opt,name=Token,proto3" json:"Token,omitempty"`
 }
 
-func (x *NodeRegistry) Reset() {
-	*x = NodeRegistry{}
+func (x *Deregistration) Reset() {
+	*x = Deregistration{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_minichord_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -252,13 +260,13 @@ func (x *NodeRegistry) Reset() {
 	}
 }
 
-func (x *NodeRegistry) String() string {
+func (x *Deregistration) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*NodeRegistry) ProtoMessage() {}
+func (*Deregistration) ProtoMessage() {}
 
-func (x *NodeRegistry) ProtoReflect() protoreflect.Message {
+func (x *Deregistration) ProtoReflect() protoreflect.Message {
 	mi := &file_minichord_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -270,50 +278,43 @@ func (x *NodeRegistry) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use NodeRegistry.ProtoReflect.Descriptor instead.
-func (*NodeRegistry) Descriptor() ([]byte, []int) {
+// Deprecated: Use Deregistration.ProtoReflect.Descriptor instead.
+func (*Deregistration) Descriptor() ([]byte, []int) {
 	return file_minichord_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *NodeRegistry) GetNR() uint32 {
+func (x *Deregistration) GetId() int32 {
 	if x != nil {
-		return x.NR
+		return x.Id
 	}
 	return 0
 }
 
-func (x *NodeRegistry) GetPeers() []*Deregistration {
-	if x != nil {
-		return x.Peers
-	}
-	return nil
-}
-
-func (x *NodeRegistry) GetNoIds() uint32 {
+func (x *Deregistration) GetAddress() string {
 	if x != nil {
-		return x.NoIds
+		return x.Address
 	}
-	return 0
+	return ""
 }
 
-func (x *NodeRegistry) GetIds() []int32 {
+func (x *Deregistration) GetToken() []byte {
 	if x != nil {
-		return x.Ids
+		return x.Token
 	}
 	return nil
 }
 
-type NodeRegistryResponse struct {
+type DeregistrationResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Result uint32 `protobuf:"fixed32,2,opt,name=Result,proto3" json:"Result,omitempty"`
+	Result int32  `protobuf:"fixed32,2,opt,name=Result,proto3" json:"Result,omitempty"`
 	Info   string `protobuf:"bytes,3,opt,name=Info,proto3" json:"Info,omitempty"`
 }
 
-func (x *NodeRegistryResponse) Reset() {
-	*x = NodeRegistryResponse{}
+func (x *DeregistrationResponse) Reset() {
+	*x = DeregistrationResponse{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_minichord_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -321,13 +322,13 @@ func (x *NodeRegistryResponse) Reset() {
 	}
 }
 
-func (x *NodeRegistryResponse) String() string {
+func (x *DeregistrationResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*NodeRegistryResponse) ProtoMessage() {}
+func (*DeregistrationResponse) ProtoMessage() {}
 
-func (x *NodeRegistryResponse) ProtoReflect() protoreflect.Message {
+func (x *DeregistrationResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_minichord_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -339,35 +340,40 @@ func (x *NodeRegistryResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use NodeRegistryResponse.ProtoReflect.Descriptor instead.
-func (*NodeRegistryResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use DeregistrationResponse.ProtoReflect.Descriptor instead.
+func (*DeregistrationResponse) Descriptor() ([]byte, []int) {
 	return file_minichord_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *NodeRegistryResponse) GetResult() uint32 {
+func (x *DeregistrationResponse) GetResult() int32 {
 	if x != nil {
 		return x.Result
 	}
 	return 0
 }
 
-func (x *NodeRegistryResponse) GetInfo() string {
+func (x *DeregistrationResponse) GetInfo() string {
 	if x != nil {
 		return x.Info
 	}
 	return ""
 }
 
-type InitiateTask struct {
+type NodeRegistry struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Packets uint32 `protobuf:"fixed32,13,opt,name=Packets,proto3" json:"Packets,omitempty"`
+	NR        uint32            `protobuf:"fixed32,4,opt,name=NR,proto3" json:"NR,omitempty"`
+	Peers     []*Deregistration `protobuf:"bytes,5,rep,name=Peers,proto3" json:"Peers,omitempty"` // Pair of Id and Address
+	NoIds     uint32            `protobuf:"fixed32,7,opt,name=NoIds,proto3" json:"NoIds,omitempty"`
+	Ids       []int32           `protobuf:"fixed32,6,rep,packed,name=Ids,proto3" json:"Ids,omitempty"`
+	DataPlane string            `protobuf:"bytes,8,opt,name=DataPlane,proto3" json:"DataPlane,omitempty"` // "udp" to send NodeData as datagrams, empty to use the registry's transport
+	Nodes     []*NodeIdentity   `protobuf:"bytes,9,rep,name=Nodes,proto3" json:"Nodes,omitempty"`         // every node in the overlay, so destinations can verify signatures
 }
 
-func (x *InitiateTask) Reset() {
-	*x = InitiateTask{}
+func (x *NodeRegistry) Reset() {
+	*x = NodeRegistry{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_minichord_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -375,13 +381,13 @@ func (x *InitiateTask) Reset() {
 	}
 }
 
-func (x *InitiateTask) String() string {
+func (x *NodeRegistry) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*InitiateTask) ProtoMessage() {}
+func (*NodeRegistry) ProtoMessage() {}
 
-func (x *InitiateTask) ProtoReflect() protoreflect.Message {
+func (x *NodeRegistry) ProtoReflect() protoreflect.Message {
 	mi := &file_minichord_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -393,32 +399,66 @@ func (x *InitiateTask) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use InitiateTask.ProtoReflect.Descriptor instead.
-func (*InitiateTask) Descriptor() ([]byte, []int) {
+// Deprecated: Use NodeRegistry.ProtoReflect.Descriptor instead.
+func (*NodeRegistry) Descriptor() ([]byte, []int) {
 	return file_minichord_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *InitiateTask) GetPackets() uint32 {
+func (x *NodeRegistry) GetNR() uint32 {
 	if x != nil {
-		return x.Packets
+		return x.NR
 	}
 	return 0
 }
 
-type NodeData struct {
+func (x *NodeRegistry) GetPeers() []*Deregistration {
+	if x != nil {
+		return x.Peers
+	}
+	return nil
+}
+
+func (x *NodeRegistry) GetNoIds() uint32 {
+	if x != nil {
+		return x.NoIds
+	}
+	return 0
+}
+
+func (x *NodeRegistry) GetIds() []int32 {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+func (x *NodeRegistry) GetDataPlane() string {
+	if x != nil {
+		return x.DataPlane
+	}
+	return ""
+}
+
+func (x *NodeRegistry) GetNodes() []*NodeIdentity {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+type NodeIdentity struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Destination int32   `protobuf:"fixed32,1,opt,name=Destination,proto3" json:"Destination,omitempty"`
-	Source      int32   `protobuf:"fixed32,2,opt,name=Source,proto3" json:"Source,omitempty"`
-	Payload     int32   `protobuf:"fixed32,3,opt,name=Payload,proto3" json:"Payload,omitempty"`
-	Hops        uint32  `protobuf:"fixed32,4,opt,name=Hops,proto3" json:"Hops,omitempty"`
-	Trace       []int32 `protobuf:"fixed32,5,rep,packed,name=Trace,proto3" json:"Trace,omitempty"`
+	Id        int32  `protobuf:"fixed32,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Address   string `protobuf:"bytes,2,opt,name=Address,proto3" json:"Address,omitempty"`
+	PublicKey []byte `protobuf:"bytes,3,opt,name=PublicKey,proto3" json:"PublicKey,omitempty"`
+	Name      string `protobuf:"bytes,4,opt,name=Name,proto3" json:"Name,omitempty"` // common name of the tls certificate the node registered with, empty without tls
 }
 
-func (x *NodeData) Reset() {
-	*x = NodeData{}
+func (x *NodeIdentity) Reset() {
+	*x = NodeIdentity{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_minichord_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -426,13 +466,13 @@ func (x *NodeData) Reset() {
 	}
 }
 
-func (x *NodeData) String() string {
+func (x *NodeIdentity) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*NodeData) ProtoMessage() {}
+func (*NodeIdentity) ProtoMessage() {}
 
-func (x *NodeData) ProtoReflect() protoreflect.Message {
+func (x *NodeIdentity) ProtoReflect() protoreflect.Message {
 	mi := &file_minichord_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -444,57 +484,51 @@ func (x *NodeData) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use NodeData.ProtoReflect.Descriptor instead.
-func (*NodeData) Descriptor() ([]byte, []int) {
+// Deprecated: Use NodeIdentity.ProtoReflect.Descriptor instead.
+func (*NodeIdentity) Descriptor() ([]byte, []int) {
 	return file_minichord_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *NodeData) GetDestination() int32 {
-	if x != nil {
-		return x.Destination
-	}
-	return 0
-}
-
-func (x *NodeData) GetSource() int32 {
+func (x *NodeIdentity) GetId() int32 {
 	if x != nil {
-		return x.Source
+		return x.Id
 	}
 	return 0
 }
 
-func (x *NodeData) GetPayload() int32 {
+func (x *NodeIdentity) GetAddress() string {
 	if x != nil {
-		return x.Payload
+		return x.Address
 	}
-	return 0
+	return ""
 }
 
-func (x *NodeData) GetHops() uint32 {
+func (x *NodeIdentity) GetPublicKey() []byte {
 	if x != nil {
-		return x.Hops
+		return x.PublicKey
 	}
-	return 0
+	return nil
 }
 
-func (x *NodeData) GetTrace() []int32 {
+func (x *NodeIdentity) GetName() string {
 	if x != nil {
-		return x.Trace
+		return x.Name
 	}
-	return nil
+	return ""
 }
 
-type TaskFinished struct {
+type NodeRegistryResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Id      int32  `protobuf:"fixed32,2,opt,name=Id,proto3" json:"Id,omitempty"`
-	Address string `protobuf:"bytes,1,opt,name=Address,proto3" json:"Address,omitempty"`
+	Result      uint32  `protobuf:"fixed32,2,opt,name=Result,proto3" json:"Result,omitempty"`
+	Info        string  `protobuf:"bytes,3,opt,name=Info,proto3" json:"Info,omitempty"`
+	Unreachable []int32 `protobuf:"fixed32,4,rep,packed,name=Unreachable,proto3" json:"Unreachable,omitempty"` // neighbours the node failed to connect to
 }
 
-func (x *TaskFinished) Reset() {
-	*x = TaskFinished{}
+func (x *NodeRegistryResponse) Reset() {
+	*x = NodeRegistryResponse{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_minichord_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -502,13 +536,13 @@ func (x *TaskFinished) Reset() {
 	}
 }
 
-func (x *TaskFinished) String() string {
+func (x *NodeRegistryResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*TaskFinished) ProtoMessage() {}
+func (*NodeRegistryResponse) ProtoMessage() {}
 
-func (x *TaskFinished) ProtoReflect() protoreflect.Message {
+func (x *NodeRegistryResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_minichord_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -520,33 +554,43 @@ func (x *TaskFinished) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use TaskFinished.ProtoReflect.Descriptor instead.
-func (*TaskFinished) Descriptor() ([]byte, []int) {
+// Deprecated: Use NodeRegistryResponse.ProtoReflect.Descriptor instead.
+func (*NodeRegistryResponse) Descriptor() ([]byte, []int) {
 	return file_minichord_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *TaskFinished) GetId() int32 {
+func (x *NodeRegistryResponse) GetResult() uint32 {
 	if x != nil {
-		return x.Id
+		return x.Result
 	}
 	return 0
 }
 
-func (x *TaskFinished) GetAddress() string {
+func (x *NodeRegistryResponse) GetInfo() string {
 	if x != nil {
-		return x.Address
+		return x.Info
 	}
 	return ""
 }
 
-type RequestTrafficSummary struct {
+func (x *NodeRegistryResponse) GetUnreachable() []int32 {
+	if x != nil {
+		return x.Unreachable
+	}
+	return nil
+}
+
+type InitiateTask struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
+
+	Packets  uint32 `protobuf:"fixed32,13,opt,name=Packets,proto3" json:"Packets,omitempty"`
+	Workload string `protobuf:"bytes,14,opt,name=Workload,proto3" json:"Workload,omitempty"` // how nodes pick the destinations of their packets, uniformly at random if empty
 }
 
-func (x *RequestTrafficSummary) Reset() {
-	*x = RequestTrafficSummary{}
+func (x *InitiateTask) Reset() {
+	*x = InitiateTask{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_minichord_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -554,13 +598,13 @@ func (x *RequestTrafficSummary) Reset() {
 	}
 }
 
-func (x *RequestTrafficSummary) String() string {
+func (x *InitiateTask) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RequestTrafficSummary) ProtoMessage() {}
+func (*InitiateTask) ProtoMessage() {}
 
-func (x *RequestTrafficSummary) ProtoReflect() protoreflect.Message {
+func (x *InitiateTask) ProtoReflect() protoreflect.Message {
 	mi := &file_minichord_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -569,44 +613,871 @@ func (x *RequestTrafficSummary) ProtoReflect() protoreflect.Message {
 		}
 		return ms
 	}
-	return mi.MessageOf(x)
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InitiateTask.ProtoReflect.Descriptor instead.
+func (*InitiateTask) Descriptor() ([]byte, []int) {
+	return file_minichord_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *InitiateTask) GetPackets() uint32 {
+	if x != nil {
+		return x.Packets
+	}
+	return 0
+}
+
+func (x *InitiateTask) GetWorkload() string {
+	if x != nil {
+		return x.Workload
+	}
+	return ""
+}
+
+// Tells the nodes to leave the overlay and exit, sent when the registry shuts down
+type Shutdown struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *Shutdown) Reset() {
+	*x = Shutdown{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_minichord_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Shutdown) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Shutdown) ProtoMessage() {}
+
+func (x *Shutdown) ProtoReflect() protoreflect.Message {
+	mi := &file_minichord_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Shutdown.ProtoReflect.Descriptor instead.
+func (*Shutdown) Descriptor() ([]byte, []int) {
+	return file_minichord_proto_rawDescGZIP(), []int{10}
+}
+
+// Stops the running task. Nodes answer with the TrafficSummary of what they got done.
+type AbortTask struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Drain bool `protobuf:"varint,1,opt,name=Drain,proto3" json:"Drain,omitempty"` // send the packets already queued before reporting, instead of discarding them
+}
+
+func (x *AbortTask) Reset() {
+	*x = AbortTask{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_minichord_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AbortTask) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AbortTask) ProtoMessage() {}
+
+func (x *AbortTask) ProtoReflect() protoreflect.Message {
+	mi := &file_minichord_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AbortTask.ProtoReflect.Descriptor instead.
+func (*AbortTask) Descriptor() ([]byte, []int) {
+	return file_minichord_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *AbortTask) GetDrain() bool {
+	if x != nil {
+		return x.Drain
+	}
+	return false
+}
+
+type NodeData struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Destination int32   `protobuf:"fixed32,1,opt,name=Destination,proto3" json:"Destination,omitempty"`
+	Source      int32   `protobuf:"fixed32,2,opt,name=Source,proto3" json:"Source,omitempty"`
+	Payload     int32   `protobuf:"fixed32,3,opt,name=Payload,proto3" json:"Payload,omitempty"`
+	Hops        uint32  `protobuf:"fixed32,4,opt,name=Hops,proto3" json:"Hops,omitempty"`
+	Trace       []int32 `protobuf:"fixed32,5,rep,packed,name=Trace,proto3" json:"Trace,omitempty"`
+	Signature   []byte  `protobuf:"bytes,6,opt,name=Signature,proto3" json:"Signature,omitempty"` // ed25519 signature of the source over Source, Destination, Payload and Sequence
+	Sequence    uint64  `protobuf:"fixed64,7,opt,name=Sequence,proto3" json:"Sequence,omitempty"` // counts the signed packets of the source for this destination, from 1, so replayed ones can be told apart
+}
+
+func (x *NodeData) Reset() {
+	*x = NodeData{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_minichord_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NodeData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeData) ProtoMessage() {}
+
+func (x *NodeData) ProtoReflect() protoreflect.Message {
+	mi := &file_minichord_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeData.ProtoReflect.Descriptor instead.
+func (*NodeData) Descriptor() ([]byte, []int) {
+	return file_minichord_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *NodeData) GetDestination() int32 {
+	if x != nil {
+		return x.Destination
+	}
+	return 0
+}
+
+func (x *NodeData) GetSource() int32 {
+	if x != nil {
+		return x.Source
+	}
+	return 0
+}
+
+func (x *NodeData) GetPayload() int32 {
+	if x != nil {
+		return x.Payload
+	}
+	return 0
+}
+
+func (x *NodeData) GetHops() uint32 {
+	if x != nil {
+		return x.Hops
+	}
+	return 0
+}
+
+func (x *NodeData) GetTrace() []int32 {
+	if x != nil {
+		return x.Trace
+	}
+	return nil
+}
+
+func (x *NodeData) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *NodeData) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+// Wraps NodeData sent over the udp data plane, a single datagram each.
+// The sequence number counts datagrams per link, so receivers can tell loss and reordering apart.
+type NodeDatagram struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sender   int32     `protobuf:"fixed32,1,opt,name=Sender,proto3" json:"Sender,omitempty"`
+	Sequence uint32    `protobuf:"fixed32,2,opt,name=Sequence,proto3" json:"Sequence,omitempty"`
+	Data     *NodeData `protobuf:"bytes,3,opt,name=Data,proto3" json:"Data,omitempty"`
+}
+
+func (x *NodeDatagram) Reset() {
+	*x = NodeDatagram{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_minichord_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NodeDatagram) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeDatagram) ProtoMessage() {}
+
+func (x *NodeDatagram) ProtoReflect() protoreflect.Message {
+	mi := &file_minichord_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeDatagram.ProtoReflect.Descriptor instead.
+func (*NodeDatagram) Descriptor() ([]byte, []int) {
+	return file_minichord_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *NodeDatagram) GetSender() int32 {
+	if x != nil {
+		return x.Sender
+	}
+	return 0
+}
+
+func (x *NodeDatagram) GetSequence() uint32 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *NodeDatagram) GetData() *NodeData {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type TaskFinished struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id      int32  `protobuf:"fixed32,2,opt,name=Id,proto3" json:"Id,omitempty"`
+	Address string `protobuf:"bytes,1,opt,name=Address,proto3" json:"Address,omitempty"`
+	Token   []byte `protobuf:"bytes,3,opt,name=Token,proto3" json:"Token,omitempty"`
+}
+
+func (x *TaskFinished) Reset() {
+	*x = TaskFinished{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_minichord_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TaskFinished) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskFinished) ProtoMessage() {}
+
+func (x *TaskFinished) ProtoReflect() protoreflect.Message {
+	mi := &file_minichord_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskFinished.ProtoReflect.Descriptor instead.
+func (*TaskFinished) Descriptor() ([]byte, []int) {
+	return file_minichord_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *TaskFinished) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *TaskFinished) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *TaskFinished) GetToken() []byte {
+	if x != nil {
+		return x.Token
+	}
+	return nil
+}
+
+type RequestTrafficSummary struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *RequestTrafficSummary) Reset() {
+	*x = RequestTrafficSummary{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_minichord_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RequestTrafficSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestTrafficSummary) ProtoMessage() {}
+
+func (x *RequestTrafficSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_minichord_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestTrafficSummary.ProtoReflect.Descriptor instead.
+func (*RequestTrafficSummary) Descriptor() ([]byte, []int) {
+	return file_minichord_proto_rawDescGZIP(), []int{15}
+}
+
+type TrafficSummary struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id            int32             `protobuf:"fixed32,2,opt,name=Id,proto3" json:"Id,omitempty"`
+	Sent          uint32            `protobuf:"fixed32,11,opt,name=Sent,proto3" json:"Sent,omitempty"`
+	Relayed       uint32            `protobuf:"fixed32,12,opt,name=Relayed,proto3" json:"Relayed,omitempty"`
+	Received      uint32            `protobuf:"fixed32,13,opt,name=Received,proto3" json:"Received,omitempty"`
+	TotalSent     int64             `protobuf:"fixed64,14,opt,name=TotalSent,proto3" json:"TotalSent,omitempty"`
+	TotalReceived int64             `protobuf:"fixed64,15,opt,name=TotalReceived,proto3" json:"TotalReceived,omitempty"`
+	Links         []*LinkStats      `protobuf:"bytes,16,rep,name=Links,proto3" json:"Links,omitempty"`
+	Forged        uint32            `protobuf:"fixed32,17,opt,name=Forged,proto3" json:"Forged,omitempty"` // packets dropped because their signature didn't match their source, or they were replayed
+	Token         []byte            `protobuf:"bytes,18,opt,name=Token,proto3" json:"Token,omitempty"`
+	Timeline      []*TimelineSecond `protobuf:"bytes,19,rep,name=Timeline,proto3" json:"Timeline,omitempty"`         // one entry per second since the node started the task
+	SentTo        []*FlowCount      `protobuf:"bytes,20,rep,name=SentTo,proto3" json:"SentTo,omitempty"`             // packets this node created, per destination
+	ReceivedFrom  []*FlowCount      `protobuf:"bytes,21,rep,name=ReceivedFrom,proto3" json:"ReceivedFrom,omitempty"` // packets that arrived for this node, per source
+	Hops          uint64            `protobuf:"fixed64,22,opt,name=Hops,proto3" json:"Hops,omitempty"`               // relays the packets that arrived for this node went through, summed
+}
+
+func (x *TrafficSummary) Reset() {
+	*x = TrafficSummary{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_minichord_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TrafficSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrafficSummary) ProtoMessage() {}
+
+func (x *TrafficSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_minichord_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrafficSummary.ProtoReflect.Descriptor instead.
+func (*TrafficSummary) Descriptor() ([]byte, []int) {
+	return file_minichord_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *TrafficSummary) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *TrafficSummary) GetSent() uint32 {
+	if x != nil {
+		return x.Sent
+	}
+	return 0
+}
+
+func (x *TrafficSummary) GetRelayed() uint32 {
+	if x != nil {
+		return x.Relayed
+	}
+	return 0
+}
+
+func (x *TrafficSummary) GetReceived() uint32 {
+	if x != nil {
+		return x.Received
+	}
+	return 0
+}
+
+func (x *TrafficSummary) GetTotalSent() int64 {
+	if x != nil {
+		return x.TotalSent
+	}
+	return 0
+}
+
+func (x *TrafficSummary) GetTotalReceived() int64 {
+	if x != nil {
+		return x.TotalReceived
+	}
+	return 0
+}
+
+func (x *TrafficSummary) GetLinks() []*LinkStats {
+	if x != nil {
+		return x.Links
+	}
+	return nil
+}
+
+func (x *TrafficSummary) GetForged() uint32 {
+	if x != nil {
+		return x.Forged
+	}
+	return 0
+}
+
+func (x *TrafficSummary) GetToken() []byte {
+	if x != nil {
+		return x.Token
+	}
+	return nil
+}
+
+func (x *TrafficSummary) GetTimeline() []*TimelineSecond {
+	if x != nil {
+		return x.Timeline
+	}
+	return nil
+}
+
+func (x *TrafficSummary) GetSentTo() []*FlowCount {
+	if x != nil {
+		return x.SentTo
+	}
+	return nil
+}
+
+func (x *TrafficSummary) GetReceivedFrom() []*FlowCount {
+	if x != nil {
+		return x.ReceivedFrom
+	}
+	return nil
+}
+
+func (x *TrafficSummary) GetHops() uint64 {
+	if x != nil {
+		return x.Hops
+	}
+	return 0
+}
+
+type FlowCount struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Node    int32  `protobuf:"fixed32,1,opt,name=Node,proto3" json:"Node,omitempty"`
+	Packets uint32 `protobuf:"fixed32,2,opt,name=Packets,proto3" json:"Packets,omitempty"`
+}
+
+func (x *FlowCount) Reset() {
+	*x = FlowCount{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_minichord_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FlowCount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FlowCount) ProtoMessage() {}
+
+func (x *FlowCount) ProtoReflect() protoreflect.Message {
+	mi := &file_minichord_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FlowCount.ProtoReflect.Descriptor instead.
+func (*FlowCount) Descriptor() ([]byte, []int) {
+	return file_minichord_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *FlowCount) GetNode() int32 {
+	if x != nil {
+		return x.Node
+	}
+	return 0
+}
+
+func (x *FlowCount) GetPackets() uint32 {
+	if x != nil {
+		return x.Packets
+	}
+	return 0
+}
+
+type TimelineSecond struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sent     uint32 `protobuf:"fixed32,1,opt,name=Sent,proto3" json:"Sent,omitempty"`
+	Relayed  uint32 `protobuf:"fixed32,2,opt,name=Relayed,proto3" json:"Relayed,omitempty"`
+	Received uint32 `protobuf:"fixed32,3,opt,name=Received,proto3" json:"Received,omitempty"`
+}
+
+func (x *TimelineSecond) Reset() {
+	*x = TimelineSecond{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_minichord_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TimelineSecond) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TimelineSecond) ProtoMessage() {}
+
+func (x *TimelineSecond) ProtoReflect() protoreflect.Message {
+	mi := &file_minichord_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TimelineSecond.ProtoReflect.Descriptor instead.
+func (*TimelineSecond) Descriptor() ([]byte, []int) {
+	return file_minichord_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *TimelineSecond) GetSent() uint32 {
+	if x != nil {
+		return x.Sent
+	}
+	return 0
+}
+
+func (x *TimelineSecond) GetRelayed() uint32 {
+	if x != nil {
+		return x.Relayed
+	}
+	return 0
+}
+
+func (x *TimelineSecond) GetReceived() uint32 {
+	if x != nil {
+		return x.Received
+	}
+	return 0
+}
+
+// Sent by nodes every second while a task runs, so the registry can show how far along it is
+type TaskProgress struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id         int32  `protobuf:"fixed32,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Sent       uint32 `protobuf:"fixed32,2,opt,name=Sent,proto3" json:"Sent,omitempty"`
+	Relayed    uint32 `protobuf:"fixed32,3,opt,name=Relayed,proto3" json:"Relayed,omitempty"`
+	Received   uint32 `protobuf:"fixed32,4,opt,name=Received,proto3" json:"Received,omitempty"`
+	QueueDepth uint32 `protobuf:"fixed32,5,opt,name=QueueDepth,proto3" json:"QueueDepth,omitempty"` // packets waiting in the node's send channel
+	Token      []byte `protobuf:"bytes,6,opt,name=Token,proto3" json:"Token,omitempty"`
+}
+
+func (x *TaskProgress) Reset() {
+	*x = TaskProgress{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_minichord_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TaskProgress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskProgress) ProtoMessage() {}
+
+func (x *TaskProgress) ProtoReflect() protoreflect.Message {
+	mi := &file_minichord_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskProgress.ProtoReflect.Descriptor instead.
+func (*TaskProgress) Descriptor() ([]byte, []int) {
+	return file_minichord_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *TaskProgress) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *TaskProgress) GetSent() uint32 {
+	if x != nil {
+		return x.Sent
+	}
+	return 0
+}
+
+func (x *TaskProgress) GetRelayed() uint32 {
+	if x != nil {
+		return x.Relayed
+	}
+	return 0
+}
+
+func (x *TaskProgress) GetReceived() uint32 {
+	if x != nil {
+		return x.Received
+	}
+	return 0
+}
+
+func (x *TaskProgress) GetQueueDepth() uint32 {
+	if x != nil {
+		return x.QueueDepth
+	}
+	return 0
+}
+
+func (x *TaskProgress) GetToken() []byte {
+	if x != nil {
+		return x.Token
+	}
+	return nil
+}
+
+type LinkStats struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Peer               int32  `protobuf:"fixed32,1,opt,name=Peer,proto3" json:"Peer,omitempty"`
+	DatagramsSent      uint32 `protobuf:"fixed32,2,opt,name=DatagramsSent,proto3" json:"DatagramsSent,omitempty"`
+	DatagramsReceived  uint32 `protobuf:"fixed32,3,opt,name=DatagramsReceived,proto3" json:"DatagramsReceived,omitempty"`
+	DatagramsReordered uint32 `protobuf:"fixed32,4,opt,name=DatagramsReordered,proto3" json:"DatagramsReordered,omitempty"`
+	PacketsSent        uint32 `protobuf:"fixed32,5,opt,name=PacketsSent,proto3" json:"PacketsSent,omitempty"`
+	BytesSent          uint64 `protobuf:"fixed64,6,opt,name=BytesSent,proto3" json:"BytesSent,omitempty"`
+	PacketsReceived    uint32 `protobuf:"fixed32,7,opt,name=PacketsReceived,proto3" json:"PacketsReceived,omitempty"`
+	BytesReceived      uint64 `protobuf:"fixed64,8,opt,name=BytesReceived,proto3" json:"BytesReceived,omitempty"`
+	SendErrors         uint32 `protobuf:"fixed32,9,opt,name=SendErrors,proto3" json:"SendErrors,omitempty"`
+	MaxQueueDepth      uint32 `protobuf:"fixed32,10,opt,name=MaxQueueDepth,proto3" json:"MaxQueueDepth,omitempty"` // deepest the send queue was when a packet left on this link
+}
+
+func (x *LinkStats) Reset() {
+	*x = LinkStats{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_minichord_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LinkStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LinkStats) ProtoMessage() {}
+
+func (x *LinkStats) ProtoReflect() protoreflect.Message {
+	mi := &file_minichord_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LinkStats.ProtoReflect.Descriptor instead.
+func (*LinkStats) Descriptor() ([]byte, []int) {
+	return file_minichord_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *LinkStats) GetPeer() int32 {
+	if x != nil {
+		return x.Peer
+	}
+	return 0
+}
+
+func (x *LinkStats) GetDatagramsSent() uint32 {
+	if x != nil {
+		return x.DatagramsSent
+	}
+	return 0
+}
+
+func (x *LinkStats) GetDatagramsReceived() uint32 {
+	if x != nil {
+		return x.DatagramsReceived
+	}
+	return 0
+}
+
+func (x *LinkStats) GetDatagramsReordered() uint32 {
+	if x != nil {
+		return x.DatagramsReordered
+	}
+	return 0
+}
+
+func (x *LinkStats) GetPacketsSent() uint32 {
+	if x != nil {
+		return x.PacketsSent
+	}
+	return 0
+}
+
+func (x *LinkStats) GetBytesSent() uint64 {
+	if x != nil {
+		return x.BytesSent
+	}
+	return 0
+}
+
+func (x *LinkStats) GetPacketsReceived() uint32 {
+	if x != nil {
+		return x.PacketsReceived
+	}
+	return 0
+}
+
+func (x *LinkStats) GetBytesReceived() uint64 {
+	if x != nil {
+		return x.BytesReceived
+	}
+	return 0
+}
+
+func (x *LinkStats) GetSendErrors() uint32 {
+	if x != nil {
+		return x.SendErrors
+	}
+	return 0
 }
 
-// Deprecated: Use RequestTrafficSummary.ProtoReflect.Descriptor instead.
-func (*RequestTrafficSummary) Descriptor() ([]byte, []int) {
-	return file_minichord_proto_rawDescGZIP(), []int{9}
+func (x *LinkStats) GetMaxQueueDepth() uint32 {
+	if x != nil {
+		return x.MaxQueueDepth
+	}
+	return 0
 }
 
-type TrafficSummary struct {
+// First message on every connection between two nodes, sent by the dialing node
+type PeerHandshake struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Id            int32  `protobuf:"fixed32,2,opt,name=Id,proto3" json:"Id,omitempty"`
-	Sent          uint32 `protobuf:"fixed32,11,opt,name=Sent,proto3" json:"Sent,omitempty"`
-	Relayed       uint32 `protobuf:"fixed32,12,opt,name=Relayed,proto3" json:"Relayed,omitempty"`
-	Received      uint32 `protobuf:"fixed32,13,opt,name=Received,proto3" json:"Received,omitempty"`
-	TotalSent     int64  `protobuf:"fixed64,14,opt,name=TotalSent,proto3" json:"TotalSent,omitempty"`
-	TotalReceived int64  `protobuf:"fixed64,15,opt,name=TotalReceived,proto3" json:"TotalReceived,omitempty"`
+	Id      int32  `protobuf:"fixed32,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Address string `protobuf:"bytes,2,opt,name=Address,proto3" json:"Address,omitempty"`
 }
 
-func (x *TrafficSummary) Reset() {
-	*x = TrafficSummary{}
+func (x *PeerHandshake) Reset() {
+	*x = PeerHandshake{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_minichord_proto_msgTypes[10]
+		mi := &file_minichord_proto_msgTypes[21]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *TrafficSummary) String() string {
+func (x *PeerHandshake) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*TrafficSummary) ProtoMessage() {}
+func (*PeerHandshake) ProtoMessage() {}
 
-func (x *TrafficSummary) ProtoReflect() protoreflect.Message {
-	mi := &file_minichord_proto_msgTypes[10]
+func (x *PeerHandshake) ProtoReflect() protoreflect.Message {
+	mi := &file_minichord_proto_msgTypes[21]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -617,51 +1488,78 @@ func (x *TrafficSummary) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use TrafficSummary.ProtoReflect.Descriptor instead.
-func (*TrafficSummary) Descriptor() ([]byte, []int) {
-	return file_minichord_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use PeerHandshake.ProtoReflect.Descriptor instead.
+func (*PeerHandshake) Descriptor() ([]byte, []int) {
+	return file_minichord_proto_rawDescGZIP(), []int{21}
 }
 
-func (x *TrafficSummary) GetId() int32 {
+func (x *PeerHandshake) GetId() int32 {
 	if x != nil {
 		return x.Id
 	}
 	return 0
 }
 
-func (x *TrafficSummary) GetSent() uint32 {
+func (x *PeerHandshake) GetAddress() string {
 	if x != nil {
-		return x.Sent
+		return x.Address
 	}
-	return 0
+	return ""
 }
 
-func (x *TrafficSummary) GetRelayed() uint32 {
-	if x != nil {
-		return x.Relayed
+type PeerHandshakeResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Result int32  `protobuf:"fixed32,1,opt,name=Result,proto3" json:"Result,omitempty"` // Id of the accepting node, -1 if the dialing node was rejected
+	Info   string `protobuf:"bytes,2,opt,name=Info,proto3" json:"Info,omitempty"`
+}
+
+func (x *PeerHandshakeResponse) Reset() {
+	*x = PeerHandshakeResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_minichord_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
 	}
-	return 0
 }
 
-func (x *TrafficSummary) GetReceived() uint32 {
-	if x != nil {
-		return x.Received
+func (x *PeerHandshakeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PeerHandshakeResponse) ProtoMessage() {}
+
+func (x *PeerHandshakeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_minichord_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return 0
+	return mi.MessageOf(x)
 }
 
-func (x *TrafficSummary) GetTotalSent() int64 {
+// Deprecated: Use PeerHandshakeResponse.ProtoReflect.Descriptor instead.
+func (*PeerHandshakeResponse) Descriptor() ([]byte, []int) {
+	return file_minichord_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *PeerHandshakeResponse) GetResult() int32 {
 	if x != nil {
-		return x.TotalSent
+		return x.Result
 	}
 	return 0
 }
 
-func (x *TrafficSummary) GetTotalReceived() int64 {
+func (x *PeerHandshakeResponse) GetInfo() string {
 	if x != nil {
-		return x.TotalReceived
+		return x.Info
 	}
-	return 0
+	return ""
 }
 
 type MiniChord struct {
@@ -682,13 +1580,20 @@ type MiniChord struct {
 	//	*MiniChord_TaskFinished
 	//	*MiniChord_RequestTrafficSummary
 	//	*MiniChord_ReportTrafficSummary
+	//	*MiniChord_PeerHandshake
+	//	*MiniChord_PeerHandshakeResponse
+	//	*MiniChord_AuthChallenge
+	//	*MiniChord_AuthResponse
+	//	*MiniChord_AbortTask
+	//	*MiniChord_TaskProgress
+	//	*MiniChord_Shutdown
 	Message isMiniChord_Message `protobuf_oneof:"Message"`
 }
 
 func (x *MiniChord) Reset() {
 	*x = MiniChord{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_minichord_proto_msgTypes[11]
+		mi := &file_minichord_proto_msgTypes[23]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -701,7 +1606,7 @@ func (x *MiniChord) String() string {
 func (*MiniChord) ProtoMessage() {}
 
 func (x *MiniChord) ProtoReflect() protoreflect.Message {
-	mi := &file_minichord_proto_msgTypes[11]
+	mi := &file_minichord_proto_msgTypes[23]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -714,7 +1619,7 @@ func (x *MiniChord) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MiniChord.ProtoReflect.Descriptor instead.
 func (*MiniChord) Descriptor() ([]byte, []int) {
-	return file_minichord_proto_rawDescGZIP(), []int{11}
+	return file_minichord_proto_rawDescGZIP(), []int{23}
 }
 
 func (m *MiniChord) GetMessage() isMiniChord_Message {
@@ -801,6 +1706,55 @@ func (x *MiniChord) GetReportTrafficSummary() *TrafficSummary {
 	return nil
 }
 
+func (x *MiniChord) GetPeerHandshake() *PeerHandshake {
+	if x, ok := x.GetMessage().(*MiniChord_PeerHandshake); ok {
+		return x.PeerHandshake
+	}
+	return nil
+}
+
+func (x *MiniChord) GetPeerHandshakeResponse() *PeerHandshakeResponse {
+	if x, ok := x.GetMessage().(*MiniChord_PeerHandshakeResponse); ok {
+		return x.PeerHandshakeResponse
+	}
+	return nil
+}
+
+func (x *MiniChord) GetAuthChallenge() *AuthChallenge {
+	if x, ok := x.GetMessage().(*MiniChord_AuthChallenge); ok {
+		return x.AuthChallenge
+	}
+	return nil
+}
+
+func (x *MiniChord) GetAuthResponse() *AuthResponse {
+	if x, ok := x.GetMessage().(*MiniChord_AuthResponse); ok {
+		return x.AuthResponse
+	}
+	return nil
+}
+
+func (x *MiniChord) GetAbortTask() *AbortTask {
+	if x, ok := x.GetMessage().(*MiniChord_AbortTask); ok {
+		return x.AbortTask
+	}
+	return nil
+}
+
+func (x *MiniChord) GetTaskProgress() *TaskProgress {
+	if x, ok := x.GetMessage().(*MiniChord_TaskProgress); ok {
+		return x.TaskProgress
+	}
+	return nil
+}
+
+func (x *MiniChord) GetShutdown() *Shutdown {
+	if x, ok := x.GetMessage().(*MiniChord_Shutdown); ok {
+		return x.Shutdown
+	}
+	return nil
+}
+
 type isMiniChord_Message interface {
 	isMiniChord_Message()
 }
@@ -849,6 +1803,34 @@ type MiniChord_ReportTrafficSummary struct {
 	ReportTrafficSummary *TrafficSummary `protobuf:"bytes,26,opt,name=reportTrafficSummary,proto3,oneof"`
 }
 
+type MiniChord_PeerHandshake struct {
+	PeerHandshake *PeerHandshake `protobuf:"bytes,27,opt,name=peerHandshake,proto3,oneof"`
+}
+
+type MiniChord_PeerHandshakeResponse struct {
+	PeerHandshakeResponse *PeerHandshakeResponse `protobuf:"bytes,28,opt,name=peerHandshakeResponse,proto3,oneof"`
+}
+
+type MiniChord_AuthChallenge struct {
+	AuthChallenge *AuthChallenge `protobuf:"bytes,29,opt,name=authChallenge,proto3,oneof"`
+}
+
+type MiniChord_AuthResponse struct {
+	AuthResponse *AuthResponse `protobuf:"bytes,30,opt,name=authResponse,proto3,oneof"`
+}
+
+type MiniChord_AbortTask struct {
+	AbortTask *AbortTask `protobuf:"bytes,31,opt,name=abortTask,proto3,oneof"`
+}
+
+type MiniChord_TaskProgress struct {
+	TaskProgress *TaskProgress `protobuf:"bytes,32,opt,name=taskProgress,proto3,oneof"`
+}
+
+type MiniChord_Shutdown struct {
+	Shutdown *Shutdown `protobuf:"bytes,33,opt,name=shutdown,proto3,oneof"`
+}
+
 func (*MiniChord_Registration) isMiniChord_Message() {}
 
 func (*MiniChord_RegistrationResponse) isMiniChord_Message() {}
@@ -871,115 +1853,260 @@ func (*MiniChord_RequestTrafficSummary) isMiniChord_Message() {}
 
 func (*MiniChord_ReportTrafficSummary) isMiniChord_Message() {}
 
+func (*MiniChord_PeerHandshake) isMiniChord_Message() {}
+
+func (*MiniChord_PeerHandshakeResponse) isMiniChord_Message() {}
+
+func (*MiniChord_AuthChallenge) isMiniChord_Message() {}
+
+func (*MiniChord_AuthResponse) isMiniChord_Message() {}
+
+func (*MiniChord_AbortTask) isMiniChord_Message() {}
+
+func (*MiniChord_TaskProgress) isMiniChord_Message() {}
+
+func (*MiniChord_Shutdown) isMiniChord_Message() {}
+
 var File_minichord_proto protoreflect.FileDescriptor
 
 var file_minichord_proto_rawDesc = []byte{
 	0x0a, 0x0f, 0x6d, 0x69, 0x6e, 0x69, 0x63, 0x68, 0x6f, 0x72, 0x64, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x12, 0x02, 0x70, 0x62, 0x22, 0x28, 0x0a, 0x0c, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72,
+	0x6f, 0x12, 0x02, 0x70, 0x62, 0x22, 0x5c, 0x0a, 0x0c, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72,
 	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x22,
-	0x42, 0x0a, 0x14, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x52, 0x65, 0x73, 0x75, 0x6c,
-	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0f, 0x52, 0x06, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12,
-	0x12, 0x0a, 0x04, 0x49, 0x6e, 0x66, 0x6f, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x49,
-	0x6e, 0x66, 0x6f, 0x22, 0x3a, 0x0a, 0x0e, 0x44, 0x65, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72,
-	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x0f, 0x52, 0x02, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x22,
-	0x44, 0x0a, 0x16, 0x44, 0x65, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f,
-	0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x52, 0x65, 0x73,
-	0x75, 0x6c, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0f, 0x52, 0x06, 0x52, 0x65, 0x73, 0x75, 0x6c,
-	0x74, 0x12, 0x12, 0x0a, 0x04, 0x49, 0x6e, 0x66, 0x6f, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x04, 0x49, 0x6e, 0x66, 0x6f, 0x22, 0x70, 0x0a, 0x0c, 0x4e, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x67,
-	0x69, 0x73, 0x74, 0x72, 0x79, 0x12, 0x0e, 0x0a, 0x02, 0x4e, 0x52, 0x18, 0x04, 0x20, 0x01, 0x28,
-	0x07, 0x52, 0x02, 0x4e, 0x52, 0x12, 0x28, 0x0a, 0x05, 0x50, 0x65, 0x65, 0x72, 0x73, 0x18, 0x05,
-	0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x62, 0x2e, 0x44, 0x65, 0x72, 0x65, 0x67, 0x69,
-	0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x05, 0x50, 0x65, 0x65, 0x72, 0x73, 0x12,
-	0x14, 0x0a, 0x05, 0x4e, 0x6f, 0x49, 0x64, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x07, 0x52, 0x05,
-	0x4e, 0x6f, 0x49, 0x64, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x49, 0x64, 0x73, 0x18, 0x06, 0x20, 0x03,
-	0x28, 0x0f, 0x52, 0x03, 0x49, 0x64, 0x73, 0x22, 0x42, 0x0a, 0x14, 0x4e, 0x6f, 0x64, 0x65, 0x52,
-	0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
-	0x16, 0x0a, 0x06, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x07, 0x52,
-	0x06, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x49, 0x6e, 0x66, 0x6f, 0x18,
-	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x49, 0x6e, 0x66, 0x6f, 0x22, 0x28, 0x0a, 0x0c, 0x49,
-	0x6e, 0x69, 0x74, 0x69, 0x61, 0x74, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x12, 0x18, 0x0a, 0x07, 0x50,
-	0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x07, 0x52, 0x07, 0x50, 0x61,
-	0x63, 0x6b, 0x65, 0x74, 0x73, 0x22, 0x88, 0x01, 0x0a, 0x08, 0x4e, 0x6f, 0x64, 0x65, 0x44, 0x61,
-	0x74, 0x61, 0x12, 0x20, 0x0a, 0x0b, 0x44, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f,
-	0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0f, 0x52, 0x0b, 0x44, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61,
-	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x0f, 0x52, 0x06, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x18, 0x0a, 0x07,
-	0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0f, 0x52, 0x07, 0x50,
-	0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x48, 0x6f, 0x70, 0x73, 0x18, 0x04,
-	0x20, 0x01, 0x28, 0x07, 0x52, 0x04, 0x48, 0x6f, 0x70, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x54, 0x72,
-	0x61, 0x63, 0x65, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0f, 0x52, 0x05, 0x54, 0x72, 0x61, 0x63, 0x65,
-	0x22, 0x38, 0x0a, 0x0c, 0x54, 0x61, 0x73, 0x6b, 0x46, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x65, 0x64,
-	0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0f, 0x52, 0x02, 0x49, 0x64,
-	0x12, 0x18, 0x0a, 0x07, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x07, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x22, 0x17, 0x0a, 0x15, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x54, 0x72, 0x61, 0x66, 0x66, 0x69, 0x63, 0x53, 0x75, 0x6d, 0x6d,
-	0x61, 0x72, 0x79, 0x22, 0xae, 0x01, 0x0a, 0x0e, 0x54, 0x72, 0x61, 0x66, 0x66, 0x69, 0x63, 0x53,
-	0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x0f, 0x52, 0x02, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x53, 0x65, 0x6e, 0x74, 0x18, 0x0b,
-	0x20, 0x01, 0x28, 0x07, 0x52, 0x04, 0x53, 0x65, 0x6e, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x52, 0x65,
-	0x6c, 0x61, 0x79, 0x65, 0x64, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x07, 0x52, 0x07, 0x52, 0x65, 0x6c,
-	0x61, 0x79, 0x65, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64,
-	0x18, 0x0d, 0x20, 0x01, 0x28, 0x07, 0x52, 0x08, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64,
-	0x12, 0x1c, 0x0a, 0x09, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x53, 0x65, 0x6e, 0x74, 0x18, 0x0e, 0x20,
-	0x01, 0x28, 0x10, 0x52, 0x09, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x53, 0x65, 0x6e, 0x74, 0x12, 0x24,
-	0x0a, 0x0d, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x18,
-	0x0f, 0x20, 0x01, 0x28, 0x10, 0x52, 0x0d, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x52, 0x65, 0x63, 0x65,
-	0x69, 0x76, 0x65, 0x64, 0x22, 0xf3, 0x05, 0x0a, 0x09, 0x4d, 0x69, 0x6e, 0x69, 0x43, 0x68, 0x6f,
-	0x72, 0x64, 0x12, 0x36, 0x0a, 0x0c, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69,
-	0x6f, 0x6e, 0x18, 0x11, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65,
-	0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x0c, 0x72, 0x65,
-	0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x4e, 0x0a, 0x14, 0x72, 0x65,
-	0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
-	0x73, 0x65, 0x18, 0x12, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12,
+	0x1c, 0x0a, 0x09, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x09, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x12, 0x14, 0x0a,
+	0x05, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x54, 0x6f,
+	0x6b, 0x65, 0x6e, 0x22, 0x58, 0x0a, 0x14, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x52,
+	0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0f, 0x52, 0x06, 0x52, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x49, 0x6e, 0x66, 0x6f, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x14, 0x0a, 0x05, 0x54, 0x6f, 0x6b, 0x65, 0x6e,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0x25, 0x0a,
+	0x0d, 0x41, 0x75, 0x74, 0x68, 0x43, 0x68, 0x61, 0x6c, 0x6c, 0x65, 0x6e, 0x67, 0x65, 0x12, 0x14,
+	0x0a, 0x05, 0x4e, 0x6f, 0x6e, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x4e,
+	0x6f, 0x6e, 0x63, 0x65, 0x22, 0x20, 0x0a, 0x0c, 0x41, 0x75, 0x74, 0x68, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x4d, 0x61, 0x63, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x03, 0x4d, 0x61, 0x63, 0x22, 0x50, 0x0a, 0x0e, 0x44, 0x65, 0x72, 0x65, 0x67, 0x69,
+	0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0f, 0x52, 0x02, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x41, 0x64, 0x64, 0x72,
+	0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x41, 0x64, 0x64, 0x72, 0x65,
+	0x73, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x05, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0x44, 0x0a, 0x16, 0x44, 0x65, 0x72, 0x65,
 	0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
-	0x73, 0x65, 0x48, 0x00, 0x52, 0x14, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69,
-	0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3c, 0x0a, 0x0e, 0x64, 0x65,
-	0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x13, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x62, 0x2e, 0x44, 0x65, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74,
-	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x0e, 0x64, 0x65, 0x72, 0x65, 0x67, 0x69,
-	0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x54, 0x0a, 0x16, 0x64, 0x65, 0x72, 0x65,
+	0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0f, 0x52, 0x06, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x49, 0x6e,
+	0x66, 0x6f, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x49, 0x6e, 0x66, 0x6f, 0x22, 0xb6,
+	0x01, 0x0a, 0x0c, 0x4e, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x79, 0x12,
+	0x0e, 0x0a, 0x02, 0x4e, 0x52, 0x18, 0x04, 0x20, 0x01, 0x28, 0x07, 0x52, 0x02, 0x4e, 0x52, 0x12,
+	0x28, 0x0a, 0x05, 0x50, 0x65, 0x65, 0x72, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12,
+	0x2e, 0x70, 0x62, 0x2e, 0x44, 0x65, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x52, 0x05, 0x50, 0x65, 0x65, 0x72, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x4e, 0x6f, 0x49,
+	0x64, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x07, 0x52, 0x05, 0x4e, 0x6f, 0x49, 0x64, 0x73, 0x12,
+	0x10, 0x0a, 0x03, 0x49, 0x64, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0f, 0x52, 0x03, 0x49, 0x64,
+	0x73, 0x12, 0x1c, 0x0a, 0x09, 0x44, 0x61, 0x74, 0x61, 0x50, 0x6c, 0x61, 0x6e, 0x65, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x44, 0x61, 0x74, 0x61, 0x50, 0x6c, 0x61, 0x6e, 0x65, 0x12,
+	0x26, 0x0a, 0x05, 0x4e, 0x6f, 0x64, 0x65, 0x73, 0x18, 0x09, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10,
+	0x2e, 0x70, 0x62, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79,
+	0x52, 0x05, 0x4e, 0x6f, 0x64, 0x65, 0x73, 0x22, 0x6a, 0x0a, 0x0c, 0x4e, 0x6f, 0x64, 0x65, 0x49,
+	0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0f, 0x52, 0x02, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x41, 0x64, 0x64, 0x72, 0x65,
+	0x73, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73,
+	0x73, 0x12, 0x1c, 0x0a, 0x09, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x12,
+	0x12, 0x0a, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x4e,
+	0x61, 0x6d, 0x65, 0x22, 0x64, 0x0a, 0x14, 0x4e, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x67, 0x69, 0x73,
+	0x74, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x52,
+	0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x07, 0x52, 0x06, 0x52, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x49, 0x6e, 0x66, 0x6f, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x20, 0x0a, 0x0b, 0x55, 0x6e, 0x72, 0x65, 0x61,
+	0x63, 0x68, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0f, 0x52, 0x0b, 0x55, 0x6e,
+	0x72, 0x65, 0x61, 0x63, 0x68, 0x61, 0x62, 0x6c, 0x65, 0x22, 0x44, 0x0a, 0x0c, 0x49, 0x6e, 0x69,
+	0x74, 0x69, 0x61, 0x74, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x12, 0x18, 0x0a, 0x07, 0x50, 0x61, 0x63,
+	0x6b, 0x65, 0x74, 0x73, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x07, 0x52, 0x07, 0x50, 0x61, 0x63, 0x6b,
+	0x65, 0x74, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x57, 0x6f, 0x72, 0x6b, 0x6c, 0x6f, 0x61, 0x64, 0x18,
+	0x0e, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x57, 0x6f, 0x72, 0x6b, 0x6c, 0x6f, 0x61, 0x64, 0x22,
+	0x0a, 0x0a, 0x08, 0x53, 0x68, 0x75, 0x74, 0x64, 0x6f, 0x77, 0x6e, 0x22, 0x21, 0x0a, 0x09, 0x41,
+	0x62, 0x6f, 0x72, 0x74, 0x54, 0x61, 0x73, 0x6b, 0x12, 0x14, 0x0a, 0x05, 0x44, 0x72, 0x61, 0x69,
+	0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x44, 0x72, 0x61, 0x69, 0x6e, 0x22, 0xc2,
+	0x01, 0x0a, 0x08, 0x4e, 0x6f, 0x64, 0x65, 0x44, 0x61, 0x74, 0x61, 0x12, 0x20, 0x0a, 0x0b, 0x44,
+	0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0f,
+	0x52, 0x0b, 0x44, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a,
+	0x06, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0f, 0x52, 0x06, 0x53,
+	0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0f, 0x52, 0x07, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12,
+	0x12, 0x0a, 0x04, 0x48, 0x6f, 0x70, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x07, 0x52, 0x04, 0x48,
+	0x6f, 0x70, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x54, 0x72, 0x61, 0x63, 0x65, 0x18, 0x05, 0x20, 0x03,
+	0x28, 0x0f, 0x52, 0x05, 0x54, 0x72, 0x61, 0x63, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x53, 0x69, 0x67,
+	0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x53, 0x69,
+	0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x53, 0x65, 0x71, 0x75, 0x65,
+	0x6e, 0x63, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x06, 0x52, 0x08, 0x53, 0x65, 0x71, 0x75, 0x65,
+	0x6e, 0x63, 0x65, 0x22, 0x64, 0x0a, 0x0c, 0x4e, 0x6f, 0x64, 0x65, 0x44, 0x61, 0x74, 0x61, 0x67,
+	0x72, 0x61, 0x6d, 0x12, 0x16, 0x0a, 0x06, 0x53, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0f, 0x52, 0x06, 0x53, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x53,
+	0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x07, 0x52, 0x08, 0x53,
+	0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x20, 0x0a, 0x04, 0x44, 0x61, 0x74, 0x61, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x70, 0x62, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x44,
+	0x61, 0x74, 0x61, 0x52, 0x04, 0x44, 0x61, 0x74, 0x61, 0x22, 0x4e, 0x0a, 0x0c, 0x54, 0x61, 0x73,
+	0x6b, 0x46, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x65, 0x64, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0f, 0x52, 0x02, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x41, 0x64, 0x64,
+	0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x41, 0x64, 0x64, 0x72,
+	0x65, 0x73, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x05, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0x17, 0x0a, 0x15, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x54, 0x72, 0x61, 0x66, 0x66, 0x69, 0x63, 0x53, 0x75, 0x6d, 0x6d, 0x61,
+	0x72, 0x79, 0x22, 0x9f, 0x03, 0x0a, 0x0e, 0x54, 0x72, 0x61, 0x66, 0x66, 0x69, 0x63, 0x53, 0x75,
+	0x6d, 0x6d, 0x61, 0x72, 0x79, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0f, 0x52, 0x02, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x53, 0x65, 0x6e, 0x74, 0x18, 0x0b, 0x20,
+	0x01, 0x28, 0x07, 0x52, 0x04, 0x53, 0x65, 0x6e, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x52, 0x65, 0x6c,
+	0x61, 0x79, 0x65, 0x64, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x07, 0x52, 0x07, 0x52, 0x65, 0x6c, 0x61,
+	0x79, 0x65, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x18,
+	0x0d, 0x20, 0x01, 0x28, 0x07, 0x52, 0x08, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x12,
+	0x1c, 0x0a, 0x09, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x53, 0x65, 0x6e, 0x74, 0x18, 0x0e, 0x20, 0x01,
+	0x28, 0x10, 0x52, 0x09, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x53, 0x65, 0x6e, 0x74, 0x12, 0x24, 0x0a,
+	0x0d, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x18, 0x0f,
+	0x20, 0x01, 0x28, 0x10, 0x52, 0x0d, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x52, 0x65, 0x63, 0x65, 0x69,
+	0x76, 0x65, 0x64, 0x12, 0x23, 0x0a, 0x05, 0x4c, 0x69, 0x6e, 0x6b, 0x73, 0x18, 0x10, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x70, 0x62, 0x2e, 0x4c, 0x69, 0x6e, 0x6b, 0x53, 0x74, 0x61, 0x74,
+	0x73, 0x52, 0x05, 0x4c, 0x69, 0x6e, 0x6b, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x46, 0x6f, 0x72, 0x67,
+	0x65, 0x64, 0x18, 0x11, 0x20, 0x01, 0x28, 0x07, 0x52, 0x06, 0x46, 0x6f, 0x72, 0x67, 0x65, 0x64,
+	0x12, 0x14, 0x0a, 0x05, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x12, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x05, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x2e, 0x0a, 0x08, 0x54, 0x69, 0x6d, 0x65, 0x6c, 0x69,
+	0x6e, 0x65, 0x18, 0x13, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x62, 0x2e, 0x54, 0x69,
+	0x6d, 0x65, 0x6c, 0x69, 0x6e, 0x65, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x52, 0x08, 0x54, 0x69,
+	0x6d, 0x65, 0x6c, 0x69, 0x6e, 0x65, 0x12, 0x25, 0x0a, 0x06, 0x53, 0x65, 0x6e, 0x74, 0x54, 0x6f,
+	0x18, 0x14, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x70, 0x62, 0x2e, 0x46, 0x6c, 0x6f, 0x77,
+	0x43, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x06, 0x53, 0x65, 0x6e, 0x74, 0x54, 0x6f, 0x12, 0x31, 0x0a,
+	0x0c, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x46, 0x72, 0x6f, 0x6d, 0x18, 0x15, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x70, 0x62, 0x2e, 0x46, 0x6c, 0x6f, 0x77, 0x43, 0x6f, 0x75,
+	0x6e, 0x74, 0x52, 0x0c, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x46, 0x72, 0x6f, 0x6d,
+	0x12, 0x12, 0x0a, 0x04, 0x48, 0x6f, 0x70, 0x73, 0x18, 0x16, 0x20, 0x01, 0x28, 0x06, 0x52, 0x04,
+	0x48, 0x6f, 0x70, 0x73, 0x22, 0x39, 0x0a, 0x09, 0x46, 0x6c, 0x6f, 0x77, 0x43, 0x6f, 0x75, 0x6e,
+	0x74, 0x12, 0x12, 0x0a, 0x04, 0x4e, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0f, 0x52,
+	0x04, 0x4e, 0x6f, 0x64, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x07, 0x52, 0x07, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x22,
+	0x5a, 0x0a, 0x0e, 0x54, 0x69, 0x6d, 0x65, 0x6c, 0x69, 0x6e, 0x65, 0x53, 0x65, 0x63, 0x6f, 0x6e,
+	0x64, 0x12, 0x12, 0x0a, 0x04, 0x53, 0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x07, 0x52,
+	0x04, 0x53, 0x65, 0x6e, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x52, 0x65, 0x6c, 0x61, 0x79, 0x65, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x07, 0x52, 0x07, 0x52, 0x65, 0x6c, 0x61, 0x79, 0x65, 0x64, 0x12,
+	0x1a, 0x0a, 0x08, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x07, 0x52, 0x08, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x22, 0x9e, 0x01, 0x0a, 0x0c,
+	0x54, 0x61, 0x73, 0x6b, 0x50, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x12, 0x0e, 0x0a, 0x02,
+	0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0f, 0x52, 0x02, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04,
+	0x53, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x07, 0x52, 0x04, 0x53, 0x65, 0x6e, 0x74,
+	0x12, 0x18, 0x0a, 0x07, 0x52, 0x65, 0x6c, 0x61, 0x79, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x07, 0x52, 0x07, 0x52, 0x65, 0x6c, 0x61, 0x79, 0x65, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x52, 0x65,
+	0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x07, 0x52, 0x08, 0x52, 0x65,
+	0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x12, 0x1e, 0x0a, 0x0a, 0x51, 0x75, 0x65, 0x75, 0x65, 0x44,
+	0x65, 0x70, 0x74, 0x68, 0x18, 0x05, 0x20, 0x01, 0x28, 0x07, 0x52, 0x0a, 0x51, 0x75, 0x65, 0x75,
+	0x65, 0x44, 0x65, 0x70, 0x74, 0x68, 0x12, 0x14, 0x0a, 0x05, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0xf9, 0x02, 0x0a,
+	0x09, 0x4c, 0x69, 0x6e, 0x6b, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x50, 0x65,
+	0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0f, 0x52, 0x04, 0x50, 0x65, 0x65, 0x72, 0x12, 0x24,
+	0x0a, 0x0d, 0x44, 0x61, 0x74, 0x61, 0x67, 0x72, 0x61, 0x6d, 0x73, 0x53, 0x65, 0x6e, 0x74, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x07, 0x52, 0x0d, 0x44, 0x61, 0x74, 0x61, 0x67, 0x72, 0x61, 0x6d, 0x73,
+	0x53, 0x65, 0x6e, 0x74, 0x12, 0x2c, 0x0a, 0x11, 0x44, 0x61, 0x74, 0x61, 0x67, 0x72, 0x61, 0x6d,
+	0x73, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x07, 0x52,
+	0x11, 0x44, 0x61, 0x74, 0x61, 0x67, 0x72, 0x61, 0x6d, 0x73, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76,
+	0x65, 0x64, 0x12, 0x2e, 0x0a, 0x12, 0x44, 0x61, 0x74, 0x61, 0x67, 0x72, 0x61, 0x6d, 0x73, 0x52,
+	0x65, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x07, 0x52, 0x12,
+	0x44, 0x61, 0x74, 0x61, 0x67, 0x72, 0x61, 0x6d, 0x73, 0x52, 0x65, 0x6f, 0x72, 0x64, 0x65, 0x72,
+	0x65, 0x64, 0x12, 0x20, 0x0a, 0x0b, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x53, 0x65, 0x6e,
+	0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x07, 0x52, 0x0b, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73,
+	0x53, 0x65, 0x6e, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x42, 0x79, 0x74, 0x65, 0x73, 0x53, 0x65, 0x6e,
+	0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x06, 0x52, 0x09, 0x42, 0x79, 0x74, 0x65, 0x73, 0x53, 0x65,
+	0x6e, 0x74, 0x12, 0x28, 0x0a, 0x0f, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x52, 0x65, 0x63,
+	0x65, 0x69, 0x76, 0x65, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x07, 0x52, 0x0f, 0x50, 0x61, 0x63,
+	0x6b, 0x65, 0x74, 0x73, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x12, 0x24, 0x0a, 0x0d,
+	0x42, 0x79, 0x74, 0x65, 0x73, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x18, 0x08, 0x20,
+	0x01, 0x28, 0x06, 0x52, 0x0d, 0x42, 0x79, 0x74, 0x65, 0x73, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76,
+	0x65, 0x64, 0x12, 0x1e, 0x0a, 0x0a, 0x53, 0x65, 0x6e, 0x64, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x73,
+	0x18, 0x09, 0x20, 0x01, 0x28, 0x07, 0x52, 0x0a, 0x53, 0x65, 0x6e, 0x64, 0x45, 0x72, 0x72, 0x6f,
+	0x72, 0x73, 0x12, 0x24, 0x0a, 0x0d, 0x4d, 0x61, 0x78, 0x51, 0x75, 0x65, 0x75, 0x65, 0x44, 0x65,
+	0x70, 0x74, 0x68, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x07, 0x52, 0x0d, 0x4d, 0x61, 0x78, 0x51, 0x75,
+	0x65, 0x75, 0x65, 0x44, 0x65, 0x70, 0x74, 0x68, 0x22, 0x39, 0x0a, 0x0d, 0x50, 0x65, 0x65, 0x72,
+	0x48, 0x61, 0x6e, 0x64, 0x73, 0x68, 0x61, 0x6b, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0f, 0x52, 0x02, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x41, 0x64, 0x64,
+	0x72, 0x65, 0x73, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x41, 0x64, 0x64, 0x72,
+	0x65, 0x73, 0x73, 0x22, 0x43, 0x0a, 0x15, 0x50, 0x65, 0x65, 0x72, 0x48, 0x61, 0x6e, 0x64, 0x73,
+	0x68, 0x61, 0x6b, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06,
+	0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0f, 0x52, 0x06, 0x52, 0x65,
+	0x73, 0x75, 0x6c, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x49, 0x6e, 0x66, 0x6f, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x49, 0x6e, 0x66, 0x6f, 0x22, 0x87, 0x09, 0x0a, 0x09, 0x4d, 0x69, 0x6e,
+	0x69, 0x43, 0x68, 0x6f, 0x72, 0x64, 0x12, 0x36, 0x0a, 0x0c, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x11, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x70,
+	0x62, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x48, 0x00,
+	0x52, 0x0c, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x4e,
+	0x0a, 0x14, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x12, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x70,
+	0x62, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00, 0x52, 0x14, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3c,
+	0x0a, 0x0e, 0x64, 0x65, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x18, 0x13, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x62, 0x2e, 0x44, 0x65, 0x72, 0x65,
+	0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x0e, 0x64, 0x65,
+	0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x54, 0x0a, 0x16,
+	0x64, 0x65, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x14, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x70,
+	0x62, 0x2e, 0x44, 0x65, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00, 0x52, 0x16, 0x64, 0x65, 0x72, 0x65,
 	0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
-	0x73, 0x65, 0x18, 0x14, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x70, 0x62, 0x2e, 0x44, 0x65,
-	0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70,
-	0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00, 0x52, 0x16, 0x64, 0x65, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74,
-	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x36,
-	0x0a, 0x0c, 0x6e, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x79, 0x18, 0x15,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x70, 0x62, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x52, 0x65,
-	0x67, 0x69, 0x73, 0x74, 0x72, 0x79, 0x48, 0x00, 0x52, 0x0c, 0x6e, 0x6f, 0x64, 0x65, 0x52, 0x65,
-	0x67, 0x69, 0x73, 0x74, 0x72, 0x79, 0x12, 0x4e, 0x0a, 0x14, 0x6e, 0x6f, 0x64, 0x65, 0x52, 0x65,
-	0x67, 0x69, 0x73, 0x74, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x16,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x70, 0x62, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x52, 0x65,
-	0x67, 0x69, 0x73, 0x74, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00,
-	0x52, 0x14, 0x6e, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x79, 0x52, 0x65,
-	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x36, 0x0a, 0x0c, 0x69, 0x6e, 0x69, 0x74, 0x69, 0x61,
-	0x74, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x18, 0x17, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x70,
-	0x62, 0x2e, 0x49, 0x6e, 0x69, 0x74, 0x69, 0x61, 0x74, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x48, 0x00,
-	0x52, 0x0c, 0x69, 0x6e, 0x69, 0x74, 0x69, 0x61, 0x74, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x12, 0x2a,
-	0x0a, 0x08, 0x6e, 0x6f, 0x64, 0x65, 0x44, 0x61, 0x74, 0x61, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x0c, 0x2e, 0x70, 0x62, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x44, 0x61, 0x74, 0x61, 0x48, 0x00,
-	0x52, 0x08, 0x6e, 0x6f, 0x64, 0x65, 0x44, 0x61, 0x74, 0x61, 0x12, 0x36, 0x0a, 0x0c, 0x74, 0x61,
-	0x73, 0x6b, 0x46, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x65, 0x64, 0x18, 0x18, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x10, 0x2e, 0x70, 0x62, 0x2e, 0x54, 0x61, 0x73, 0x6b, 0x46, 0x69, 0x6e, 0x69, 0x73, 0x68,
-	0x65, 0x64, 0x48, 0x00, 0x52, 0x0c, 0x74, 0x61, 0x73, 0x6b, 0x46, 0x69, 0x6e, 0x69, 0x73, 0x68,
-	0x65, 0x64, 0x12, 0x51, 0x0a, 0x15, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x54, 0x72, 0x61,
-	0x66, 0x66, 0x69, 0x63, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x18, 0x19, 0x20, 0x01, 0x28,
-	0x0b, 0x32, 0x19, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x54, 0x72,
-	0x61, 0x66, 0x66, 0x69, 0x63, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x48, 0x00, 0x52, 0x15,
-	0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x54, 0x72, 0x61, 0x66, 0x66, 0x69, 0x63, 0x53, 0x75,
-	0x6d, 0x6d, 0x61, 0x72, 0x79, 0x12, 0x48, 0x0a, 0x14, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x54,
-	0x72, 0x61, 0x66, 0x66, 0x69, 0x63, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x18, 0x1a, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x62, 0x2e, 0x54, 0x72, 0x61, 0x66, 0x66, 0x69, 0x63,
-	0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x48, 0x00, 0x52, 0x14, 0x72, 0x65, 0x70, 0x6f, 0x72,
-	0x74, 0x54, 0x72, 0x61, 0x66, 0x66, 0x69, 0x63, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x42,
-	0x09, 0x0a, 0x07, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x42, 0x21, 0x5a, 0x1f, 0x67, 0x69,
-	0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6d, 0x6b, 0x79, 0x61, 0x73, 0x2f, 0x63,
-	0x61, 0x64, 0x70, 0x2f, 0x6d, 0x69, 0x6e, 0x69, 0x63, 0x68, 0x6f, 0x72, 0x64, 0x62, 0x06, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x73, 0x65, 0x12, 0x36, 0x0a, 0x0c, 0x6e, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74,
+	0x72, 0x79, 0x18, 0x15, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x70, 0x62, 0x2e, 0x4e, 0x6f,
+	0x64, 0x65, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x79, 0x48, 0x00, 0x52, 0x0c, 0x6e, 0x6f,
+	0x64, 0x65, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x79, 0x12, 0x4e, 0x0a, 0x14, 0x6e, 0x6f,
+	0x64, 0x65, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x18, 0x16, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x70, 0x62, 0x2e, 0x4e, 0x6f,
+	0x64, 0x65, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x48, 0x00, 0x52, 0x14, 0x6e, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74,
+	0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x36, 0x0a, 0x0c, 0x69, 0x6e,
+	0x69, 0x74, 0x69, 0x61, 0x74, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x18, 0x17, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x10, 0x2e, 0x70, 0x62, 0x2e, 0x49, 0x6e, 0x69, 0x74, 0x69, 0x61, 0x74, 0x65, 0x54, 0x61,
+	0x73, 0x6b, 0x48, 0x00, 0x52, 0x0c, 0x69, 0x6e, 0x69, 0x74, 0x69, 0x61, 0x74, 0x65, 0x54, 0x61,
+	0x73, 0x6b, 0x12, 0x2a, 0x0a, 0x08, 0x6e, 0x6f, 0x64, 0x65, 0x44, 0x61, 0x74, 0x61, 0x18, 0x0f,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x70, 0x62, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x44, 0x61,
+	0x74, 0x61, 0x48, 0x00, 0x52, 0x08, 0x6e, 0x6f, 0x64, 0x65, 0x44, 0x61, 0x74, 0x61, 0x12, 0x36,
+	0x0a, 0x0c, 0x74, 0x61, 0x73, 0x6b, 0x46, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x65, 0x64, 0x18, 0x18,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x70, 0x62, 0x2e, 0x54, 0x61, 0x73, 0x6b, 0x46, 0x69,
+	0x6e, 0x69, 0x73, 0x68, 0x65, 0x64, 0x48, 0x00, 0x52, 0x0c, 0x74, 0x61, 0x73, 0x6b, 0x46, 0x69,
+	0x6e, 0x69, 0x73, 0x68, 0x65, 0x64, 0x12, 0x51, 0x0a, 0x15, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x54, 0x72, 0x61, 0x66, 0x66, 0x69, 0x63, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x18,
+	0x19, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x54, 0x72, 0x61, 0x66, 0x66, 0x69, 0x63, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79,
+	0x48, 0x00, 0x52, 0x15, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x54, 0x72, 0x61, 0x66, 0x66,
+	0x69, 0x63, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x12, 0x48, 0x0a, 0x14, 0x72, 0x65, 0x70,
+	0x6f, 0x72, 0x74, 0x54, 0x72, 0x61, 0x66, 0x66, 0x69, 0x63, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72,
+	0x79, 0x18, 0x1a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x62, 0x2e, 0x54, 0x72, 0x61,
+	0x66, 0x66, 0x69, 0x63, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x48, 0x00, 0x52, 0x14, 0x72,
+	0x65, 0x70, 0x6f, 0x72, 0x74, 0x54, 0x72, 0x61, 0x66, 0x66, 0x69, 0x63, 0x53, 0x75, 0x6d, 0x6d,
+	0x61, 0x72, 0x79, 0x12, 0x39, 0x0a, 0x0d, 0x70, 0x65, 0x65, 0x72, 0x48, 0x61, 0x6e, 0x64, 0x73,
+	0x68, 0x61, 0x6b, 0x65, 0x18, 0x1b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x70, 0x62, 0x2e,
+	0x50, 0x65, 0x65, 0x72, 0x48, 0x61, 0x6e, 0x64, 0x73, 0x68, 0x61, 0x6b, 0x65, 0x48, 0x00, 0x52,
+	0x0d, 0x70, 0x65, 0x65, 0x72, 0x48, 0x61, 0x6e, 0x64, 0x73, 0x68, 0x61, 0x6b, 0x65, 0x12, 0x51,
+	0x0a, 0x15, 0x70, 0x65, 0x65, 0x72, 0x48, 0x61, 0x6e, 0x64, 0x73, 0x68, 0x61, 0x6b, 0x65, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x1c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e,
+	0x70, 0x62, 0x2e, 0x50, 0x65, 0x65, 0x72, 0x48, 0x61, 0x6e, 0x64, 0x73, 0x68, 0x61, 0x6b, 0x65,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00, 0x52, 0x15, 0x70, 0x65, 0x65, 0x72,
+	0x48, 0x61, 0x6e, 0x64, 0x73, 0x68, 0x61, 0x6b, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x39, 0x0a, 0x0d, 0x61, 0x75, 0x74, 0x68, 0x43, 0x68, 0x61, 0x6c, 0x6c, 0x65, 0x6e,
+	0x67, 0x65, 0x18, 0x1d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x70, 0x62, 0x2e, 0x41, 0x75,
+	0x74, 0x68, 0x43, 0x68, 0x61, 0x6c, 0x6c, 0x65, 0x6e, 0x67, 0x65, 0x48, 0x00, 0x52, 0x0d, 0x61,
+	0x75, 0x74, 0x68, 0x43, 0x68, 0x61, 0x6c, 0x6c, 0x65, 0x6e, 0x67, 0x65, 0x12, 0x36, 0x0a, 0x0c,
+	0x61, 0x75, 0x74, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x1e, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x10, 0x2e, 0x70, 0x62, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00, 0x52, 0x0c, 0x61, 0x75, 0x74, 0x68, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2d, 0x0a, 0x09, 0x61, 0x62, 0x6f, 0x72, 0x74, 0x54, 0x61, 0x73,
+	0x6b, 0x18, 0x1f, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x70, 0x62, 0x2e, 0x41, 0x62, 0x6f,
+	0x72, 0x74, 0x54, 0x61, 0x73, 0x6b, 0x48, 0x00, 0x52, 0x09, 0x61, 0x62, 0x6f, 0x72, 0x74, 0x54,
+	0x61, 0x73, 0x6b, 0x12, 0x36, 0x0a, 0x0c, 0x74, 0x61, 0x73, 0x6b, 0x50, 0x72, 0x6f, 0x67, 0x72,
+	0x65, 0x73, 0x73, 0x18, 0x20, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x70, 0x62, 0x2e, 0x54,
+	0x61, 0x73, 0x6b, 0x50, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x48, 0x00, 0x52, 0x0c, 0x74,
+	0x61, 0x73, 0x6b, 0x50, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x12, 0x2a, 0x0a, 0x08, 0x73,
+	0x68, 0x75, 0x74, 0x64, 0x6f, 0x77, 0x6e, 0x18, 0x21, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e,
+	0x70, 0x62, 0x2e, 0x53, 0x68, 0x75, 0x74, 0x64, 0x6f, 0x77, 0x6e, 0x48, 0x00, 0x52, 0x08, 0x73,
+	0x68, 0x75, 0x74, 0x64, 0x6f, 0x77, 0x6e, 0x42, 0x09, 0x0a, 0x07, 0x4d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x42, 0x21, 0x5a, 0x1f, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d,
+	0x2f, 0x6d, 0x6b, 0x79, 0x61, 0x73, 0x2f, 0x63, 0x61, 0x64, 0x70, 0x2f, 0x6d, 0x69, 0x6e, 0x69,
+	0x63, 0x68, 0x6f, 0x72, 0x64, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -994,39 +2121,64 @@ func file_minichord_proto_rawDescGZIP() []byte {
 	return file_minichord_proto_rawDescData
 }
 
-var file_minichord_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_minichord_proto_msgTypes = make([]protoimpl.MessageInfo, 24)
 var file_minichord_proto_goTypes = []interface{}{
 	(*Registration)(nil),           // 0: pb.Registration
 	(*RegistrationResponse)(nil),   // 1: pb.RegistrationResponse
-	(*Deregistration)(nil),         // 2: pb.Deregistration
-	(*DeregistrationResponse)(nil), // 3: pb.DeregistrationResponse
-	(*NodeRegistry)(nil),           // 4: pb.NodeRegistry
-	(*NodeRegistryResponse)(nil),   // 5: pb.NodeRegistryResponse
-	(*InitiateTask)(nil),           // 6: pb.InitiateTask
-	(*NodeData)(nil),               // 7: pb.NodeData
-	(*TaskFinished)(nil),           // 8: pb.TaskFinished
-	(*RequestTrafficSummary)(nil),  // 9: pb.RequestTrafficSummary
-	(*TrafficSummary)(nil),         // 10: pb.TrafficSummary
-	(*MiniChord)(nil),              // 11: pb.MiniChord
+	(*AuthChallenge)(nil),          // 2: pb.AuthChallenge
+	(*AuthResponse)(nil),           // 3: pb.AuthResponse
+	(*Deregistration)(nil),         // 4: pb.Deregistration
+	(*DeregistrationResponse)(nil), // 5: pb.DeregistrationResponse
+	(*NodeRegistry)(nil),           // 6: pb.NodeRegistry
+	(*NodeIdentity)(nil),           // 7: pb.NodeIdentity
+	(*NodeRegistryResponse)(nil),   // 8: pb.NodeRegistryResponse
+	(*InitiateTask)(nil),           // 9: pb.InitiateTask
+	(*Shutdown)(nil),               // 10: pb.Shutdown
+	(*AbortTask)(nil),              // 11: pb.AbortTask
+	(*NodeData)(nil),               // 12: pb.NodeData
+	(*NodeDatagram)(nil),           // 13: pb.NodeDatagram
+	(*TaskFinished)(nil),           // 14: pb.TaskFinished
+	(*RequestTrafficSummary)(nil),  // 15: pb.RequestTrafficSummary
+	(*TrafficSummary)(nil),         // 16: pb.TrafficSummary
+	(*FlowCount)(nil),              // 17: pb.FlowCount
+	(*TimelineSecond)(nil),         // 18: pb.TimelineSecond
+	(*TaskProgress)(nil),           // 19: pb.TaskProgress
+	(*LinkStats)(nil),              // 20: pb.LinkStats
+	(*PeerHandshake)(nil),          // 21: pb.PeerHandshake
+	(*PeerHandshakeResponse)(nil),  // 22: pb.PeerHandshakeResponse
+	(*MiniChord)(nil),              // 23: pb.MiniChord
 }
 var file_minichord_proto_depIdxs = []int32{
-	2,  // 0: pb.NodeRegistry.Peers:type_name -> pb.Deregistration
-	0,  // 1: pb.MiniChord.registration:type_name -> pb.Registration
-	1,  // 2: pb.MiniChord.registrationResponse:type_name -> pb.RegistrationResponse
-	2,  // 3: pb.MiniChord.deregistration:type_name -> pb.Deregistration
-	3,  // 4: pb.MiniChord.deregistrationResponse:type_name -> pb.DeregistrationResponse
-	4,  // 5: pb.MiniChord.nodeRegistry:type_name -> pb.NodeRegistry
-	5,  // 6: pb.MiniChord.nodeRegistryResponse:type_name -> pb.NodeRegistryResponse
-	6,  // 7: pb.MiniChord.initiateTask:type_name -> pb.InitiateTask
-	7,  // 8: pb.MiniChord.nodeData:type_name -> pb.NodeData
-	8,  // 9: pb.MiniChord.taskFinished:type_name -> pb.TaskFinished
-	9,  // 10: pb.MiniChord.requestTrafficSummary:type_name -> pb.RequestTrafficSummary
-	10, // 11: pb.MiniChord.reportTrafficSummary:type_name -> pb.TrafficSummary
-	12, // [12:12] is the sub-list for method output_type
-	12, // [12:12] is the sub-list for method input_type
-	12, // [12:12] is the sub-list for extension type_name
-	12, // [12:12] is the sub-list for extension extendee
-	0,  // [0:12] is the sub-list for field type_name
+	4,  // 0: pb.NodeRegistry.Peers:type_name -> pb.Deregistration
+	7,  // 1: pb.NodeRegistry.Nodes:type_name -> pb.NodeIdentity
+	12, // 2: pb.NodeDatagram.Data:type_name -> pb.NodeData
+	20, // 3: pb.TrafficSummary.Links:type_name -> pb.LinkStats
+	18, // 4: pb.TrafficSummary.Timeline:type_name -> pb.TimelineSecond
+	17, // 5: pb.TrafficSummary.SentTo:type_name -> pb.FlowCount
+	17, // 6: pb.TrafficSummary.ReceivedFrom:type_name -> pb.FlowCount
+	0,  // 7: pb.MiniChord.registration:type_name -> pb.Registration
+	1,  // 8: pb.MiniChord.registrationResponse:type_name -> pb.RegistrationResponse
+	4,  // 9: pb.MiniChord.deregistration:type_name -> pb.Deregistration
+	5,  // 10: pb.MiniChord.deregistrationResponse:type_name -> pb.DeregistrationResponse
+	6,  // 11: pb.MiniChord.nodeRegistry:type_name -> pb.NodeRegistry
+	8,  // 12: pb.MiniChord.nodeRegistryResponse:type_name -> pb.NodeRegistryResponse
+	9,  // 13: pb.MiniChord.initiateTask:type_name -> pb.InitiateTask
+	12, // 14: pb.MiniChord.nodeData:type_name -> pb.NodeData
+	14, // 15: pb.MiniChord.taskFinished:type_name -> pb.TaskFinished
+	15, // 16: pb.MiniChord.requestTrafficSummary:type_name -> pb.RequestTrafficSummary
+	16, // 17: pb.MiniChord.reportTrafficSummary:type_name -> pb.TrafficSummary
+	21, // 18: pb.MiniChord.peerHandshake:type_name -> pb.PeerHandshake
+	22, // 19: pb.MiniChord.peerHandshakeResponse:type_name -> pb.PeerHandshakeResponse
+	2,  // 20: pb.MiniChord.authChallenge:type_name -> pb.AuthChallenge
+	3,  // 21: pb.MiniChord.authResponse:type_name -> pb.AuthResponse
+	11, // 22: pb.MiniChord.abortTask:type_name -> pb.AbortTask
+	19, // 23: pb.MiniChord.taskProgress:type_name -> pb.TaskProgress
+	10, // 24: pb.MiniChord.shutdown:type_name -> pb.Shutdown
+	25, // [25:25] is the sub-list for method output_type
+	25, // [25:25] is the sub-list for method input_type
+	25, // [25:25] is the sub-list for extension type_name
+	25, // [25:25] is the sub-list for extension extendee
+	0,  // [0:25] is the sub-list for field type_name
 }
 
 func init() { file_minichord_proto_init() }
@@ -1060,7 +2212,7 @@ func file_minichord_proto_init() {
 			}
 		}
 		file_minichord_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Deregistration); i {
+			switch v := v.(*AuthChallenge); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1072,7 +2224,7 @@ func file_minichord_proto_init() {
 			}
 		}
 		file_minichord_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*DeregistrationResponse); i {
+			switch v := v.(*AuthResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1084,7 +2236,7 @@ func file_minichord_proto_init() {
 			}
 		}
 		file_minichord_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*NodeRegistry); i {
+			switch v := v.(*Deregistration); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1096,7 +2248,7 @@ func file_minichord_proto_init() {
 			}
 		}
 		file_minichord_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*NodeRegistryResponse); i {
+			switch v := v.(*DeregistrationResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1108,7 +2260,7 @@ func file_minichord_proto_init() {
 			}
 		}
 		file_minichord_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*InitiateTask); i {
+			switch v := v.(*NodeRegistry); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1120,7 +2272,7 @@ func file_minichord_proto_init() {
 			}
 		}
 		file_minichord_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*NodeData); i {
+			switch v := v.(*NodeIdentity); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1132,7 +2284,7 @@ func file_minichord_proto_init() {
 			}
 		}
 		file_minichord_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TaskFinished); i {
+			switch v := v.(*NodeRegistryResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1144,7 +2296,7 @@ func file_minichord_proto_init() {
 			}
 		}
 		file_minichord_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*RequestTrafficSummary); i {
+			switch v := v.(*InitiateTask); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1156,7 +2308,7 @@ func file_minichord_proto_init() {
 			}
 		}
 		file_minichord_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TrafficSummary); i {
+			switch v := v.(*Shutdown); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1168,6 +2320,150 @@ func file_minichord_proto_init() {
 			}
 		}
 		file_minichord_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AbortTask); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_minichord_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NodeData); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_minichord_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NodeDatagram); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_minichord_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TaskFinished); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_minichord_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RequestTrafficSummary); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_minichord_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TrafficSummary); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_minichord_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FlowCount); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_minichord_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TimelineSecond); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_minichord_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TaskProgress); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_minichord_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LinkStats); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_minichord_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PeerHandshake); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_minichord_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PeerHandshakeResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_minichord_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*MiniChord); i {
 			case 0:
 				return &v.state
@@ -1180,7 +2476,7 @@ func file_minichord_proto_init() {
 			}
 		}
 	}
-	file_minichord_proto_msgTypes[11].OneofWrappers = []interface{}{
+	file_minichord_proto_msgTypes[23].OneofWrappers = []interface{}{
 		(*MiniChord_Registration)(nil),
 		(*MiniChord_RegistrationResponse)(nil),
 		(*MiniChord_Deregistration)(nil),
@@ -1192,6 +2488,13 @@ func file_minichord_proto_init() {
 		(*MiniChord_TaskFinished)(nil),
 		(*MiniChord_RequestTrafficSummary)(nil),
 		(*MiniChord_ReportTrafficSummary)(nil),
+		(*MiniChord_PeerHandshake)(nil),
+		(*MiniChord_PeerHandshakeResponse)(nil),
+		(*MiniChord_AuthChallenge)(nil),
+		(*MiniChord_AuthResponse)(nil),
+		(*MiniChord_AbortTask)(nil),
+		(*MiniChord_TaskProgress)(nil),
+		(*MiniChord_Shutdown)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -1199,7 +2502,7 @@ func file_minichord_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_minichord_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   12,
+			NumMessages:   24,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,245 @@
+package registry
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lsig/OverlayNetwork/logger"
+)
+
+// The parameters of a single task in a sweep
+type ExperimentRound struct {
+	TableSize int
+	Topology  string
+	Packets   int
+	Workload  string
+	Repeat    int // counts the tasks run with the same parameters, from 1
+}
+
+// A sweep over parameters, run on the nodes registered when it started, one task per round
+type Experiment struct {
+	Rounds  []ExperimentRound
+	Next    int    // the round running, or to run once the overlay is set up for it
+	Runs    []*Run // of the rounds that ended
+	Path    string // the results table is written here
+	Stopped bool   // the operator stopped a task, so no further rounds are run
+}
+
+func (r *Registry) HandleExperimentCmd(spec string) {
+	r.Packets <- &Packet{Event: ExperimentRequested, Argument: spec}
+}
+
+// Parses a sweep such as table=1,2,3 packets=1000,10000 topology=chord,random workload=uniform,hotspot repeat=3 out=sweep.csv.
+// Parameters that are left out stay as the overlay is set up, or default to 1000 packets picking destinations uniformly.
+func (r *Registry) parseExperiment(spec string) (*Experiment, error) {
+	tables := []int{max(r.RTableSize, 1)}
+	topologies := []string{r.Topology}
+	packets := []int{1000}
+	workloads := []string{UniformWorkload}
+	repeat := 1
+	path := fmt.Sprintf("experiment-%s.csv", time.Now().Format("20060102-150405"))
+	if r.OutputDir != "" {
+		path = filepath.Join(r.OutputDir, path)
+	}
+
+	ints := func(values []string) ([]int, error) {
+		result := []int{}
+		for _, value := range values {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%q is not a positive number", value)
+			}
+			result = append(result, n)
+		}
+		return result, nil
+	}
+
+	for _, field := range strings.Fields(spec) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("expected key=value, got %q", field)
+		}
+		values := strings.Split(value, ",")
+		var err error
+		switch key {
+		case "table":
+			tables, err = ints(values)
+		case "packets":
+			packets, err = ints(values)
+		case "repeat":
+			var repeats []int
+			if repeats, err = ints(values); err == nil && len(repeats) > 1 {
+				err = fmt.Errorf("takes a single number, got %s", value)
+			} else if err == nil {
+				repeat = repeats[0]
+			}
+		case "topology":
+			for _, topology := range values {
+				if !slices.Contains(Topologies, topology) {
+					return nil, fmt.Errorf("unknown topology %q, must be one of %s", topology, strings.Join(Topologies, ", "))
+				}
+			}
+			topologies = values
+		case "workload":
+			workloads = []string{}
+			for _, name := range values {
+				workload, ok := Workloads[name]
+				if !ok {
+					return nil, fmt.Errorf("unknown workload %q", name)
+				}
+				workloads = append(workloads, workload)
+			}
+		case "out":
+			path = value
+		default:
+			return nil, fmt.Errorf("unknown parameter %q", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", key, err)
+		}
+	}
+
+	experiment := &Experiment{Path: path}
+	// rounds with the same routing tables follow each other, so the overlay is only set up again when they change
+	for _, topology := range topologies {
+		for _, table := range tables {
+			for _, workload := range workloads {
+				for _, n := range packets {
+					for i := range repeat {
+						experiment.Rounds = append(experiment.Rounds, ExperimentRound{TableSize: table, Topology: topology, Packets: n, Workload: workload, Repeat: i + 1})
+					}
+				}
+			}
+		}
+	}
+	return experiment, nil
+}
+
+func (r *Registry) HandleExperiment(spec string) {
+	if r.Experiment != nil {
+		logger.Error("An experiment is running")
+		return
+	}
+	if r.StartComplete || (r.SetupSent && !r.SetupComplete) {
+		logger.Error("Wait for the running setup or task to end first")
+		return
+	}
+
+	experiment, err := r.parseExperiment(spec)
+	if err != nil {
+		logger.Errorf("Invalid experiment: %v", err)
+		return
+	}
+
+	fmt.Printf("Experiment of %d rounds on %d nodes, results go to %s\n", len(experiment.Rounds), len(r.Keys), experiment.Path)
+	r.Experiment = experiment
+	r.startExperimentRound()
+}
+
+// Sets the overlay up for the next round, unless it already is, in which case its task starts right away
+func (r *Registry) startExperimentRound() {
+	experiment := r.Experiment
+	if experiment.Stopped || experiment.Next == len(experiment.Rounds) {
+		r.endExperiment()
+		return
+	}
+
+	round := experiment.Rounds[experiment.Next]
+	fmt.Printf("Experiment round %d/%d: %s topology, routing table size %d, %d packets, %s workload, repeat %d\n",
+		experiment.Next+1,
+		len(experiment.Rounds),
+		round.Topology,
+		round.TableSize,
+		round.Packets,
+		workloadName(round.Workload),
+		round.Repeat,
+	)
+
+	size := min(round.TableSize, maxRoutingTableSize(round.Topology, len(r.Keys)))
+	if r.SetupComplete && r.Topology == round.Topology && r.RTableSize == size {
+		r.startExperimentTask()
+		return
+	}
+	if !r.setup(round.TableSize, round.Topology) {
+		r.endExperiment()
+	}
+}
+
+// Starts the task of the current round, once the overlay is set up for it
+func (r *Registry) startExperimentTask() {
+	round := r.Experiment.Rounds[r.Experiment.Next]
+	r.HandleInitiateTask(newInitiateTask(round.Packets, round.Workload))
+}
+
+func (r *Registry) nextExperimentRound(run *Run) {
+	r.Experiment.Runs = append(r.Experiment.Runs, run)
+	r.Experiment.Next++
+	r.startExperimentRound()
+}
+
+// Prints the results table of the experiment and writes it to its csv file
+func (r *Registry) endExperiment() {
+	experiment := r.Experiment
+	r.Experiment = nil
+
+	if len(experiment.Runs) < len(experiment.Rounds) {
+		fmt.Printf("Experiment ended after %d of %d rounds\n", len(experiment.Runs), len(experiment.Rounds))
+	}
+
+	rows := [][]string{{"round", "topology", "table_size", "workload", "packets", "repeat", "nodes", "run", "completion_seconds", "throughput", "path_length", "loss_percent", "verdict"}}
+	for i, run := range experiment.Runs {
+		round := experiment.Rounds[i]
+		rows = append(rows, []string{
+			strconv.Itoa(i + 1),
+			run.Topology,
+			strconv.Itoa(run.RoutingTableSize),
+			workloadName(round.Workload),
+			strconv.Itoa(run.Packets),
+			strconv.Itoa(round.Repeat),
+			strconv.Itoa(run.Nodes),
+			strconv.Itoa(run.Run),
+			strconv.FormatFloat(run.CompletionTime().Seconds(), 'f', 3, 64),
+			strconv.FormatFloat(run.Throughput(), 'f', 0, 64),
+			strconv.FormatFloat(run.PathLength(), 'f', 2, 64),
+			strconv.FormatFloat(run.Loss(), 'f', 2, 64),
+			run.Verdict,
+		})
+	}
+
+	for _, row := range rows {
+		fmt.Println(strings.Join(row, "\t"))
+	}
+
+	if err := writeCSV(experiment.Path, rows); err != nil {
+		logger.Errorf("Failed to write the experiment results: %v", err)
+	} else {
+		fmt.Printf("Experiment results written to %s\n", experiment.Path)
+	}
+
+	if r.ExitWhenIdle {
+		r.exitBatch()
+	}
+}
+
+func writeCSV(path string, rows [][]string) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	writer.WriteAll(rows)
+	return writer.Error()
+}
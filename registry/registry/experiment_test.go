@@ -0,0 +1,86 @@
+package registry
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseExperiment(t *testing.T) {
+	tests := []struct {
+		spec   string
+		rounds []ExperimentRound
+		path   string
+		fails  bool
+	}{
+		{
+			spec:   "",
+			rounds: []ExperimentRound{{TableSize: 3, Topology: RingTopology, Packets: 1000, Workload: UniformWorkload, Repeat: 1}},
+		},
+		{
+			spec: "table=1,2 packets=10 repeat=2 out=sweep.csv",
+			rounds: []ExperimentRound{
+				{TableSize: 1, Topology: RingTopology, Packets: 10, Workload: UniformWorkload, Repeat: 1},
+				{TableSize: 1, Topology: RingTopology, Packets: 10, Workload: UniformWorkload, Repeat: 2},
+				{TableSize: 2, Topology: RingTopology, Packets: 10, Workload: UniformWorkload, Repeat: 1},
+				{TableSize: 2, Topology: RingTopology, Packets: 10, Workload: UniformWorkload, Repeat: 2},
+			},
+			path: "sweep.csv",
+		},
+		{
+			spec: "topology=chord,random workload=uniform,hotspot packets=5",
+			rounds: []ExperimentRound{
+				{TableSize: 3, Topology: ChordTopology, Packets: 5, Workload: UniformWorkload, Repeat: 1},
+				{TableSize: 3, Topology: ChordTopology, Packets: 5, Workload: HotspotWorkload, Repeat: 1},
+				{TableSize: 3, Topology: RandomTopology, Packets: 5, Workload: UniformWorkload, Repeat: 1},
+				{TableSize: 3, Topology: RandomTopology, Packets: 5, Workload: HotspotWorkload, Repeat: 1},
+			},
+		},
+		{spec: "table=0", fails: true},
+		{spec: "packets=-1", fails: true},
+		{spec: "repeat=two", fails: true},
+		{spec: "repeat=0", fails: true},
+		{spec: "repeat=2,3", fails: true},
+		{spec: "topology=star", fails: true},
+		{spec: "workload=bursty", fails: true},
+		{spec: "nodes=3", fails: true},
+		{spec: "table", fails: true},
+		{spec: "table=", fails: true},
+	}
+
+	r := &Registry{RTableSize: 3, Topology: RingTopology, OutputDir: "results"}
+	for _, test := range tests {
+		experiment, err := r.parseExperiment(test.spec)
+		if test.fails {
+			if err == nil {
+				t.Errorf("parseExperiment(%q) = %+v, want an error", test.spec, experiment.Rounds)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseExperiment(%q) failed: %v", test.spec, err)
+			continue
+		}
+		if !slices.Equal(experiment.Rounds, test.rounds) {
+			t.Errorf("parseExperiment(%q) = %+v, want %+v", test.spec, experiment.Rounds, test.rounds)
+		}
+		if test.path != "" && experiment.Path != test.path {
+			t.Errorf("parseExperiment(%q) writes to %s, want %s", test.spec, experiment.Path, test.path)
+		}
+		if test.path == "" && (filepath.Dir(experiment.Path) != "results" || !strings.HasPrefix(filepath.Base(experiment.Path), "experiment-")) {
+			t.Errorf("parseExperiment(%q) writes to %s, want results/experiment-<time>.csv", test.spec, experiment.Path)
+		}
+	}
+}
+
+func TestParseExperimentBeforeSetup(t *testing.T) {
+	r := &Registry{Topology: ChordTopology}
+	experiment, err := r.parseExperiment("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if experiment.Rounds[0].TableSize != 1 {
+		t.Errorf("table size before any setup is %d, want 1", experiment.Rounds[0].TableSize)
+	}
+}
@@ -0,0 +1,251 @@
+package registry
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/lsig/OverlayNetwork/logger"
+)
+
+// A task that has ended, with the parameters it ran with and what every node reported.
+// This is the schema of the json file written per run, see the README.
+type Run struct {
+	Run              int            `json:"run"`
+	Started          time.Time      `json:"started"`
+	Finished         time.Time      `json:"finished"` // when the last node sent TaskFinished, zero if the task was aborted first
+	Ended            time.Time      `json:"ended"`    // when the run concluded, after the traffic summaries
+	Packets          int            `json:"packets"`  // per node
+	Nodes            int            `json:"nodes"`
+	RoutingTableSize int            `json:"routing_table_size"`
+	Topology         string         `json:"topology"`
+	Workload         string         `json:"workload"`
+	Transport        string         `json:"transport"`
+	DataPlane        string         `json:"data_plane"`
+	Aborted          bool           `json:"aborted"`
+	Verdict          string         `json:"verdict"`
+	RoutingTables    []RoutingTable `json:"routing_tables"`
+	Summaries        []NodeSummary  `json:"summaries"`
+	Timeline         []TimelineRow  `json:"timeline"`
+}
+
+type RoutingTable struct {
+	Node    int32  `json:"node"`
+	Address string `json:"address"`
+	Peers   []Peer `json:"peers"`
+}
+
+type Peer struct {
+	Node    int32  `json:"node"`
+	Address string `json:"address"`
+}
+
+type NodeSummary struct {
+	Node          int32            `json:"node"`
+	Sent          uint32           `json:"sent"`
+	Received      uint32           `json:"received"`
+	Relayed       uint32           `json:"relayed"`
+	TotalSent     int64            `json:"total_sent"`
+	TotalReceived int64            `json:"total_received"`
+	Forged        uint32           `json:"forged"`
+	Hops          uint64           `json:"hops"`
+	SentTo        map[int32]uint32 `json:"sent_to"`
+	ReceivedFrom  map[int32]uint32 `json:"received_from"`
+	Links         []LinkSummary    `json:"links"`
+}
+
+type LinkSummary struct {
+	Peer            int32  `json:"peer"`
+	PacketsSent     uint32 `json:"packets_sent"`
+	BytesSent       uint64 `json:"bytes_sent"`
+	PacketsReceived uint32 `json:"packets_received"`
+	BytesReceived   uint64 `json:"bytes_received"`
+	SendErrors      uint32 `json:"send_errors"`
+	MaxQueueDepth   uint32 `json:"max_queue_depth"`
+}
+
+type TimelineRow struct {
+	Second   int    `json:"second"`
+	Sent     uint32 `json:"sent"`
+	Relayed  uint32 `json:"relayed"`
+	Received uint32 `json:"received"`
+}
+
+// Collects the run that is concluding, before the registry resets its state for the next one
+func (r *Registry) newRun() *Run {
+	dataPlane := r.DataPlane
+	if dataPlane == StreamDataPlane {
+		dataPlane = "stream"
+	}
+
+	run := &Run{
+		Run:              r.Runs,
+		Started:          r.RunStarted,
+		Finished:         r.RunFinished,
+		Ended:            time.Now(),
+		Packets:          r.NoPackets,
+		Nodes:            len(r.Keys),
+		RoutingTableSize: r.RTableSize,
+		Topology:         r.Topology,
+		Workload:         workloadName(r.Workload),
+		Transport:        r.Transport.Name(),
+		DataPlane:        dataPlane,
+		Aborted:          r.Aborted,
+		Verdict:          r.Verdict.String(),
+		RoutingTables:    []RoutingTable{},
+		Summaries:        []NodeSummary{},
+		Timeline:         []TimelineRow{},
+	}
+
+	ids := slices.Clone(r.Keys)
+	slices.Sort(ids)
+	for _, id := range ids {
+		node := r.Nodes[id]
+		table := RoutingTable{Node: id, Address: node.Address, Peers: []Peer{}}
+		for peer, address := range node.RoutingTable {
+			table.Peers = append(table.Peers, Peer{Node: peer, Address: address})
+		}
+		slices.SortFunc(table.Peers, func(a, b Peer) int { return int(a.Node - b.Node) })
+		run.RoutingTables = append(run.RoutingTables, table)
+	}
+
+	for _, s := range r.Summaries {
+		summary := NodeSummary{
+			Node:          s.Id,
+			Sent:          s.Sent,
+			Received:      s.Received,
+			Relayed:       s.Relayed,
+			TotalSent:     s.TotalSent,
+			TotalReceived: s.TotalReceived,
+			Forged:        s.Forged,
+			Hops:          s.Hops,
+			SentTo:        s.SentTo,
+			ReceivedFrom:  s.ReceivedFrom,
+			Links:         []LinkSummary{},
+		}
+		for _, link := range s.Links {
+			summary.Links = append(summary.Links, LinkSummary{
+				Peer:            link.Peer,
+				PacketsSent:     link.PacketsSent,
+				BytesSent:       link.BytesSent,
+				PacketsReceived: link.PacketsReceived,
+				BytesReceived:   link.BytesReceived,
+				SendErrors:      link.SendErrors,
+				MaxQueueDepth:   link.MaxQueueDepth,
+			})
+		}
+		run.Summaries = append(run.Summaries, summary)
+	}
+	slices.SortFunc(run.Summaries, func(a, b NodeSummary) int { return int(a.Node - b.Node) })
+
+	for i, second := range mergeTimelines(r.Summaries) {
+		run.Timeline = append(run.Timeline, TimelineRow{Second: i, Sent: second.Sent, Relayed: second.Relayed, Received: second.Received})
+	}
+	return run
+}
+
+// Writes the run to <dir>/run-<started>-<run>.json, and its summaries to a csv file of the same name
+func exportRun(dir string, run *Run) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	base := filepath.Join(dir, fmt.Sprintf("run-%s-%d", run.Started.Format("20060102-150405"), run.Run))
+
+	content, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(base+".json", append(content, '\n'), 0o644); err != nil {
+		return "", err
+	}
+
+	if err := writeSummariesCSV(base+".csv", run.Summaries); err != nil {
+		return "", err
+	}
+	return base, nil
+}
+
+// Reads the runs exported to dir by earlier registries into the history, so history and compare cover them as well.
+// Runs are numbered on from the highest one read. Files that can't be read are skipped.
+func (r *Registry) LoadHistory(dir string) {
+	paths, err := filepath.Glob(filepath.Join(dir, "run-*.json"))
+	if err != nil {
+		logger.Errorf("Failed to read the runs in %s: %v", dir, err)
+		return
+	}
+
+	runs := []*Run{}
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			logger.Warningf("Skipping %s: %v", path, err)
+			continue
+		}
+		run := &Run{}
+		if err := json.Unmarshal(content, run); err != nil {
+			logger.Warningf("Skipping %s: %v", path, err)
+			continue
+		}
+		runs = append(runs, run)
+	}
+	if len(runs) == 0 {
+		return
+	}
+
+	slices.SortFunc(runs, func(a, b *Run) int {
+		if c := a.Started.Compare(b.Started); c != 0 {
+			return c
+		}
+		return a.Run - b.Run
+	})
+	r.History = append(runs, r.History...)
+	for _, run := range runs {
+		r.LoadedRuns = max(r.LoadedRuns, run.Run)
+	}
+	r.Runs = max(r.Runs, r.LoadedRuns)
+	logger.Infof("Loaded %d earlier runs from %s, the next one is run %d", len(runs), dir, r.Runs+1)
+}
+
+// Writes one row per node below a header, followed by a row of totals with node set to total
+func writeSummariesCSV(path string, summaries []NodeSummary) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"node", "sent", "received", "relayed", "total_sent", "total_received", "forged", "hops"})
+
+	var total NodeSummary
+	row := func(node string, s NodeSummary) []string {
+		return []string{
+			node,
+			strconv.FormatUint(uint64(s.Sent), 10),
+			strconv.FormatUint(uint64(s.Received), 10),
+			strconv.FormatUint(uint64(s.Relayed), 10),
+			strconv.FormatInt(s.TotalSent, 10),
+			strconv.FormatInt(s.TotalReceived, 10),
+			strconv.FormatUint(uint64(s.Forged), 10),
+			strconv.FormatUint(s.Hops, 10),
+		}
+	}
+	for _, s := range summaries {
+		writer.Write(row(strconv.Itoa(int(s.Node)), s))
+		total.Sent += s.Sent
+		total.Received += s.Received
+		total.Relayed += s.Relayed
+		total.TotalSent += s.TotalSent
+		total.TotalReceived += s.TotalReceived
+		total.Forged += s.Forged
+		total.Hops += s.Hops
+	}
+	writer.Write(row("total", total))
+	writer.Flush()
+	return writer.Error()
+}
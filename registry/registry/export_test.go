@@ -0,0 +1,37 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadHistory(t *testing.T) {
+	dir := t.TempDir()
+	started := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, run := range []int{7, 2, 4} {
+		content, err := json.Marshal(&Run{Run: run, Started: started.Add(time.Duration(run) * time.Minute)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("run-%d.json", i)), content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "run-broken.json"), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &Registry{}
+	r.LoadHistory(dir)
+
+	if len(r.History) != 3 || r.History[0].Run != 2 || r.History[2].Run != 7 {
+		t.Errorf("loaded %d runs, want runs 2, 4 and 7 in the order they started", len(r.History))
+	}
+	if r.Runs != 7 || r.LoadedRuns != 7 {
+		t.Errorf("Runs %d and LoadedRuns %d, want 7 each", r.Runs, r.LoadedRuns)
+	}
+}
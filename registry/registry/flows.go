@@ -0,0 +1,139 @@
+package registry
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/lsig/OverlayNetwork/logger"
+)
+
+// The packets a source created for a destination, and how many of them arrived
+type Flow struct {
+	Source      int32
+	Destination int32
+	Sent        uint32
+	Received    uint32
+	Unreported  bool // the source or the destination didn't report its summary, so one side is unknown
+}
+
+func (f Flow) Missing() uint32 {
+	return f.Sent - min(f.Received, f.Sent)
+}
+
+// Pairs the packets every node sent to each destination with the packets the destination received from it,
+// ordered by source and destination
+func computeFlows(summaries []Summary) []Flow {
+	reported := map[int32]bool{}
+	for _, s := range summaries {
+		reported[s.Id] = true
+	}
+
+	type flowKey struct{ source, destination int32 }
+	flows := map[flowKey]*Flow{}
+	get := func(source int32, destination int32) *Flow {
+		key := flowKey{source, destination}
+		if _, ok := flows[key]; !ok {
+			flows[key] = &Flow{Source: source, Destination: destination, Unreported: !reported[source] || !reported[destination]}
+		}
+		return flows[key]
+	}
+
+	for _, s := range summaries {
+		for destination, packets := range s.SentTo {
+			get(s.Id, destination).Sent = packets
+		}
+		for source, packets := range s.ReceivedFrom {
+			get(source, s.Id).Received = packets
+		}
+	}
+
+	result := []Flow{}
+	for _, flow := range flows {
+		result = append(result, *flow)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Source != result[j].Source {
+			return result[i].Source < result[j].Source
+		}
+		return result[i].Destination < result[j].Destination
+	})
+	return result
+}
+
+// How many incomplete flows are named below the summaries, the rest are in the flows command
+const maxNamedFlows = 20
+
+// Names the flows that lost packets, or that can't be checked because a node didn't report
+func printMissingFlows(flows []Flow) {
+	incomplete := []Flow{}
+	var missing uint32
+	for _, flow := range flows {
+		if flow.Unreported || flow.Received != flow.Sent {
+			incomplete = append(incomplete, flow)
+			missing += flow.Missing()
+		}
+	}
+
+	if len(incomplete) == 0 {
+		fmt.Printf("Flows | %d source/destination pairs, none missing packets\n", len(flows))
+		return
+	}
+
+	for i, flow := range incomplete {
+		if i == maxNamedFlows {
+			fmt.Printf("... and %d more, see flows\n", len(incomplete)-maxNamedFlows)
+			break
+		}
+		switch {
+		case flow.Unreported:
+			fmt.Printf("Flow %d -> %d: sent %d, received %d, not every node reported\n", flow.Source, flow.Destination, flow.Sent, flow.Received)
+		case flow.Received > flow.Sent:
+			fmt.Printf("Flow %d -> %d: sent %d, received %d, %d more than sent\n", flow.Source, flow.Destination, flow.Sent, flow.Received, flow.Received-flow.Sent)
+		default:
+			fmt.Printf("Flow %d -> %d: sent %d, received %d, missing %d\n", flow.Source, flow.Destination, flow.Sent, flow.Received, flow.Missing())
+		}
+	}
+	fmt.Printf("Flows | %d source/destination pairs, %d incomplete, %d packets missing\n", len(flows), len(incomplete), missing)
+}
+
+// Prints the packets missing per source (rows) and destination (columns) of the last task
+func (r *Registry) printFlowMatrix() {
+	if len(r.Results) == 0 {
+		logger.Error("No task has ended yet")
+		return
+	}
+
+	type flowKey struct{ source, destination int32 }
+	flows := map[flowKey]Flow{}
+	ids := []int32{}
+	for _, flow := range computeFlows(r.Results) {
+		flows[flowKey{flow.Source, flow.Destination}] = flow
+		ids = append(ids, flow.Source, flow.Destination)
+	}
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	fmt.Println("Packets missing per source (rows) and destination (columns), ? where a node didn't report")
+	fmt.Print("From\\To")
+	for _, id := range ids {
+		fmt.Printf("\t%d", id)
+	}
+	fmt.Println()
+	for _, source := range ids {
+		fmt.Printf("%d", source)
+		for _, destination := range ids {
+			flow, ok := flows[flowKey{source, destination}]
+			switch {
+			case !ok:
+				fmt.Print("\t.")
+			case flow.Unreported:
+				fmt.Print("\t?")
+			default:
+				fmt.Printf("\t%d", flow.Missing())
+			}
+		}
+		fmt.Println()
+	}
+	printMissingFlows(computeFlows(r.Results))
+}
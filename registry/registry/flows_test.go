@@ -0,0 +1,72 @@
+package registry
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestComputeFlows(t *testing.T) {
+	tests := []struct {
+		name      string
+		summaries []Summary
+		flows     []Flow
+	}{
+		{name: "none", summaries: nil, flows: []Flow{}},
+		{
+			name: "complete",
+			summaries: []Summary{
+				{Id: 1, SentTo: map[int32]uint32{2: 3}, ReceivedFrom: map[int32]uint32{2: 4}},
+				{Id: 2, SentTo: map[int32]uint32{1: 4}, ReceivedFrom: map[int32]uint32{1: 3}},
+			},
+			flows: []Flow{
+				{Source: 1, Destination: 2, Sent: 3, Received: 3},
+				{Source: 2, Destination: 1, Sent: 4, Received: 4},
+			},
+		},
+		{
+			name: "lost and extra packets",
+			summaries: []Summary{
+				{Id: 1, SentTo: map[int32]uint32{2: 5, 3: 1}},
+				{Id: 2, ReceivedFrom: map[int32]uint32{1: 2}},
+				{Id: 3, ReceivedFrom: map[int32]uint32{1: 2}},
+			},
+			flows: []Flow{
+				{Source: 1, Destination: 2, Sent: 5, Received: 2},
+				{Source: 1, Destination: 3, Sent: 1, Received: 2},
+			},
+		},
+		{
+			name: "unreported nodes",
+			summaries: []Summary{
+				{Id: 1, SentTo: map[int32]uint32{2: 5}, ReceivedFrom: map[int32]uint32{3: 1}},
+			},
+			flows: []Flow{
+				{Source: 1, Destination: 2, Sent: 5, Unreported: true},
+				{Source: 3, Destination: 1, Received: 1, Unreported: true},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		if flows := computeFlows(test.summaries); !slices.Equal(flows, test.flows) {
+			t.Errorf("%s: computeFlows = %+v, want %+v", test.name, flows, test.flows)
+		}
+	}
+}
+
+func TestFlowMissing(t *testing.T) {
+	tests := []struct {
+		flow    Flow
+		missing uint32
+	}{
+		{flow: Flow{Sent: 5, Received: 5}, missing: 0},
+		{flow: Flow{Sent: 5, Received: 2}, missing: 3},
+		{flow: Flow{Sent: 1, Received: 2}, missing: 0},
+	}
+
+	for _, test := range tests {
+		if missing := test.flow.Missing(); missing != test.missing {
+			t.Errorf("%+v.Missing() = %d, want %d", test.flow, missing, test.missing)
+		}
+	}
+}
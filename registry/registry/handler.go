@@ -1,26 +1,97 @@
 package registry
 
 import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"errors"
 	"fmt"
-	"math"
 	"net"
-	"os"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/lsig/OverlayNetwork/auth"
 	"github.com/lsig/OverlayNetwork/logger"
 	pb "github.com/lsig/OverlayNetwork/pb"
+	"github.com/lsig/OverlayNetwork/transport"
 )
 
 func (r *Registry) HandleRegistration(conn net.Conn, msg *pb.MiniChord_Registration) {
+	// a node presenting a session token lost its connection and wants to pick up where it left off
+	if len(msg.Registration.GetToken()) != 0 {
+		r.resume(conn, msg.Registration)
+		return
+	}
+
+	// the node is told, rather than left waiting for a RegistrationResponse
+	if r.SetupSent {
+		r.register(conn, msg.Registration, errLateRegistration)
+		return
+	}
+
+	// with a pre-shared key, the node must answer a challenge before its registration is looked at
+	if r.SharedKey != nil {
+		r.sendAuthChallenge(conn, msg.Registration)
+		return
+	}
+
+	r.register(conn, msg.Registration, nil)
+}
+
+func (r *Registry) sendAuthChallenge(conn net.Conn, registration *pb.Registration) {
+	nonce, err := auth.NewNonce()
+	if err != nil {
+		logger.Errorf("Failed to generate challenge: %v", err)
+		return
+	}
+	r.Challenges[conn] = &Challenge{Nonce: nonce, Registration: registration}
+
+	chordMessage := &pb.MiniChord{
+		Message: &pb.MiniChord_AuthChallenge{
+			AuthChallenge: &pb.AuthChallenge{Nonce: nonce},
+		},
+	}
+
+	if err := r.SendMessage(conn, chordMessage); err != nil {
+		logger.Errorf("Failed to send authentication challenge: %v", err)
+		delete(r.Challenges, conn)
+	}
+}
+
+func (r *Registry) HandleAuthResponse(conn net.Conn, msg *pb.MiniChord_AuthResponse) {
+	challenge, ok := r.Challenges[conn]
+	if !ok {
+		logger.Error("Received authentication response without a pending challenge")
+		return
+	}
+	delete(r.Challenges, conn)
+
 	if r.SetupSent {
-		logger.Error("Can't register after setup has been executed")
+		r.register(conn, challenge.Registration, errLateRegistration)
+		return
+	}
+
+	registrationAddr := challenge.Registration.GetAddress()
+	if !auth.ChallengeResponseIsValid(r.SharedKey, challenge.Nonce, registrationAddr, msg.AuthResponse.GetMac()) {
+		r.register(conn, challenge.Registration, errors.New("Registration request unsuccessful: Authentication failed."))
 		return
 	}
 
+	r.register(conn, challenge.Registration, nil)
+}
+
+// Nodes can't join once the routing tables have been sent out
+var errLateRegistration = errors.New("Registration request unsuccessful: The overlay has already been set up.")
+
+// Validates and adds a node, and tells it the outcome.
+// A non-nil rejection fails the registration before anything else is checked.
+func (r *Registry) register(conn net.Conn, registration *pb.Registration, rejection error) {
 	var info string
 	success := true
 
-	registrationAddr := msg.Registration.GetAddress()
+	registrationAddr := registration.GetAddress()
 
 	if !verifyAddress(registrationAddr, conn.RemoteAddr().String()) {
 		success = false
@@ -32,9 +103,51 @@ func (r *Registry) HandleRegistration(conn net.Conn, msg *pb.MiniChord_Registrat
 		info = "Registration request unsuccessful: Address already exists."
 	}
 
-	id := r.AddNode(registrationAddr, conn)
+	publicKey := registration.GetPublicKey()
+	if len(publicKey) != 0 && len(publicKey) != ed25519.PublicKeySize {
+		success = false
+		info = "Registration request unsuccessful: Public key is not an ed25519 key."
+	}
+
+	// with tls, the address must also be one the node's certificate was issued for
+	identity := ""
+	if certificate := transport.PeerCertificate(conn); certificate != nil {
+		identity = certificate.Subject.CommonName
+		host, _, _ := net.SplitHostPort(registrationAddr)
+
+		if err := certificate.VerifyHostname(host); err != nil {
+			success = false
+			info = fmt.Sprintf("Registration request unsuccessful: Address not covered by the certificate of %s.", identity)
+		} else if r.IdentityExists(identity) {
+			success = false
+			info = fmt.Sprintf("Registration request unsuccessful: %s is already registered.", identity)
+		}
+	}
 
+	if rejection != nil {
+		success = false
+		info = rejection.Error()
+	}
+
+	// nothing is changed unless every check passed, so a rejected node doesn't use up an Id
+	id := int32(-1)
+	if success {
+		id = r.AddNode(registrationAddr, conn)
+		if id == -1 {
+			success = false
+			info = "Registration request unsuccessful: The overlay is full."
+		}
+	}
+
+	var token []byte
 	if success {
+		token = auth.IssueToken(r.TokenKey, id)
+		node := r.Nodes[id]
+		node.Identity = identity
+		node.PublicKey = publicKey
+		node.Token = token
+		r.Sessions[conn] = &Session{Conn: conn, NodeId: id}
+
 		info = fmt.Sprintf("Registration request successful. The number of messaging nodes currently constituting the overlay is (%d).", len(r.Keys))
 		logger.Info(info)
 	} else {
@@ -44,6 +157,7 @@ func (r *Registry) HandleRegistration(conn net.Conn, msg *pb.MiniChord_Registrat
 	res := &pb.RegistrationResponse{
 		Result: id,
 		Info:   info,
+		Token:  token,
 	}
 
 	chordMessage := &pb.MiniChord{
@@ -63,6 +177,75 @@ func (r *Registry) HandleRegistration(conn net.Conn, msg *pb.MiniChord_Registrat
 	}
 }
 
+// Rebinds a node that registered earlier to the connection it presents its session token on.
+// The node keeps its Id and state, and gets the last request sent to it again, as the old connection may have swallowed it.
+func (r *Registry) resume(conn net.Conn, registration *pb.Registration) {
+	var info string
+	success := true
+
+	id, err := auth.ParseToken(r.TokenKey, registration.GetToken())
+	node, ok := r.Nodes[id]
+
+	switch {
+	case err != nil:
+		success = false
+		info = fmt.Sprintf("Resume request unsuccessful: %s.", err.Error())
+	case !ok || node.State == Gone:
+		success = false
+		info = fmt.Sprintf("Resume request unsuccessful: Node %d is no longer part of the overlay.", id)
+	case !bytes.Equal(node.Token, registration.GetToken()):
+		success = false
+		info = fmt.Sprintf("Resume request unsuccessful: Token of node %d has been replaced.", id)
+	case node.Address != registration.GetAddress():
+		success = false
+		info = fmt.Sprintf("Resume request unsuccessful: Node %d registered with address %s.", id, node.Address)
+	case !verifyAddress(registration.GetAddress(), conn.RemoteAddr().String()):
+		success = false
+		info = "Resume request unsuccessful: Address mismatch."
+	}
+
+	res := &pb.RegistrationResponse{Result: -1, Info: info}
+
+	if success {
+		delete(r.Sessions, node.Conn)
+		node.Conn.Close()
+		node.Conn = conn
+		node.Failed = false
+		r.Sessions[conn] = &Session{Conn: conn, NodeId: id}
+
+		info = fmt.Sprintf("Node %d resumed its session in state %s.", id, node.State)
+		logger.Info(info)
+		res = &pb.RegistrationResponse{Result: id, Info: info, Token: node.Token}
+	} else {
+		logger.Error(info)
+	}
+
+	chordMessage := &pb.MiniChord{
+		Message: &pb.MiniChord_RegistrationResponse{
+			RegistrationResponse: res,
+		},
+	}
+
+	if err := r.SendMessage(conn, chordMessage); err != nil {
+		logger.Errorf("Failed to send resume response: %v", err)
+		return
+	}
+
+	if !success {
+		return
+	}
+
+	// a node that has answered its last request has nothing to repeat.
+	// A Running node answers a repeated InitiateTask with its TaskFinished, if it sent one that got lost
+	_, initiated := node.LastRequest.GetMessage().(*pb.MiniChord_InitiateTask)
+	finished := node.State == Finished && initiated
+	if node.LastRequest != nil && !finished && (node.State == Configured || node.State == Running || node.State == Finished) {
+		if err := r.SendMessage(conn, node.LastRequest); err != nil {
+			logger.Errorf("Failed to repeat last request to node %d: %v", id, err)
+		}
+	}
+}
+
 func (r *Registry) HandleDeregistration(conn net.Conn, msg *pb.MiniChord_Deregistration) {
 	var info string
 	var id int32
@@ -75,6 +258,16 @@ func (r *Registry) HandleDeregistration(conn net.Conn, msg *pb.MiniChord_Deregis
 
 	registrationAddr := msg.Deregistration.GetAddress()
 
+	if success && !r.tokenIsValid(msg.Deregistration.GetToken(), msg.Deregistration.GetId(), "Deregistration") {
+		success = false
+		info = "Deregistration request unsuccessful: Invalid session token."
+	}
+
+	if success && r.sessionNode(conn, msg.Deregistration.GetId(), "Deregistration") == nil {
+		success = false
+		info = "Deregistration request unsuccessful: Node is not registered on this connection."
+	}
+
 	if success && !verifyAddress(registrationAddr, conn.RemoteAddr().String()) {
 		success = false
 		info = "Deregistration request unsuccessful: Address mismatch."
@@ -87,6 +280,7 @@ func (r *Registry) HandleDeregistration(conn net.Conn, msg *pb.MiniChord_Deregis
 
 	if success {
 		info = fmt.Sprintf("Deregistration request successful. Node Id: (%d) not longer exists. The number of messaging nodes currently constituting the overlay is (%d).", id, len(r.Keys))
+		r.Nodes[msg.Deregistration.GetId()].State = Gone
 		id = r.RemoveNode(msg.Deregistration.GetId())
 		logger.Info(info)
 	} else {
@@ -117,54 +311,61 @@ func (r *Registry) HandleDeregistration(conn net.Conn, msg *pb.MiniChord_Deregis
 }
 
 func (r *Registry) HandleNodeRegistry() {
-	for _, node := range r.Nodes {
-		peers := []*pb.Deregistration{}
-		for key, val := range node.RoutingTable {
-			info := &pb.Deregistration{
-				Id:      key,
-				Address: val,
-			}
-			peers = append(peers, info)
-		}
-		nodeRegistry := &pb.NodeRegistry{
-			NR:    uint32(len(node.RoutingTable)),
-			NoIds: uint32(len(r.Keys)),
-			Peers: peers,
-			Ids:   r.Keys,
-		}
-		chordMessage := &pb.MiniChord{
-			Message: &pb.MiniChord_NodeRegistry{
-				NodeRegistry: nodeRegistry,
-			},
-		}
-
-		if err := r.SendMessage(node.Conn, chordMessage); err != nil {
-			errMsg := fmt.Sprintf("Failed to send NodeRegistry request: %v", err)
-			logger.Error(errMsg)
-		}
-		logger.Info(fmt.Sprintf("Succesfully sent NodeRegistry to node %d", node.Id))
-	}
 	r.SetupSent = true
+	r.SetupComplete = false
+	r.SetupAttempt = 0
+	r.sendNodeRegistry(r.Keys)
 }
 
-func (r *Registry) HandleNodeRegistryResponse(res *pb.MiniChord_NodeRegistryResponse) {
+func (r *Registry) HandleNodeRegistryResponse(conn net.Conn, res *pb.MiniChord_NodeRegistryResponse) {
+	var node *Node
+
 	if res.NodeRegistryResponse.Result > 127 {
-		logger.Error("Node Failed to connect to Nodes in Routing table. Exiting ...")
-		os.Exit(1)
+		// a node that failed its setup doesn't put its Id in the result, so it's known by its connection
+		session, ok := r.Sessions[conn]
+		if !ok {
+			logger.Errorf("Rejected NodeRegistryResponse: no node registered on connection %s", conn.RemoteAddr().String())
+			return
+		}
+		node = r.Nodes[session.NodeId]
+		if node.State != Configured {
+			logger.Errorf("Rejected NodeRegistryResponse from node %d: node is %s, expected %s", node.Id, node.State, Configured)
+			return
+		}
+
+		node.SetupFailure = res.NodeRegistryResponse
+		logger.Errorf("Node %d failed its setup: %s", node.Id, res.NodeRegistryResponse.GetInfo())
+	} else {
+		node = r.sessionNode(conn, int32(res.NodeRegistryResponse.Result), "NodeRegistryResponse")
+		if node == nil || !r.advance(node, Configured, Ready, "NodeRegistryResponse") {
+			return
+		}
 	}
-	r.NoSetupNodes++
 
-	if r.NoSetupNodes == len(r.Keys) {
-		logger.Info("The registry is now ready to initiate tasks.")
-		r.SetupComplete = true
+	for _, node := range r.Nodes {
+		if node.State == Configured && node.SetupFailure == nil {
+			// still waiting for an answer
+			return
+		}
 	}
+	r.concludeSetup()
 }
 
 func (r *Registry) HandleInitiateTask(task *pb.MiniChord) {
+	r.Runs++
+	r.RunStarted = time.Now()
+	r.RunFinished = time.Time{}
+	r.NoPackets = int(task.GetInitiateTask().GetPackets())
+	r.Workload = task.GetInitiateTask().GetWorkload()
+	r.Progress = map[int32]*Progress{}
 	for _, node := range r.Nodes {
+		node.State = Running
+		node.LastRequest = task
+
 		if err := r.SendMessage(node.Conn, task); err != nil {
 			errMsg := fmt.Sprintf("Failed to send InitiateTask request: %v", err)
 			logger.Error(errMsg)
+			continue
 		}
 		logger.Info(fmt.Sprintf("Succesfully sent InitiateTask to node %d", node.Id))
 	}
@@ -172,23 +373,121 @@ func (r *Registry) HandleInitiateTask(task *pb.MiniChord) {
 }
 
 func (r *Registry) HandleTaskFinished(conn net.Conn, msg *pb.MiniChord_TaskFinished) {
-	if msg.TaskFinished.Id > 127 {
-		logger.Error("Node Failed to Finish Sending Messages. Exiting ...")
-		os.Exit(1)
+	// unknown ids, and ids of nodes registered on another connection, are dropped like any other message of theirs
+	if !r.tokenIsValid(msg.TaskFinished.GetToken(), msg.TaskFinished.GetId(), "TaskFinished") {
+		return
+	}
+	node := r.sessionNode(conn, msg.TaskFinished.GetId(), "TaskFinished")
+	if node == nil {
+		return
 	}
 	if !verifyAddress(msg.TaskFinished.GetAddress(), conn.RemoteAddr().String()) {
-		logger.Error("Node address does not match the connection address")
+		logger.Errorf("Rejected TaskFinished from node %d: address %s does not match the connection address %s", node.Id, msg.TaskFinished.GetAddress(), conn.RemoteAddr().String())
+		return
+	}
+	if r.Aborted {
+		// crossed the AbortTask on its way here
+		logger.Infof("Ignoring TaskFinished from node %d, the task was aborted", node.Id)
+		return
+	}
+	if !r.advance(node, Running, Finished, "TaskFinished") {
 		return
 	}
 
 	r.NoFinished++
 
 	if r.NoFinished == len(r.Keys) {
-		// Sleep for 5 seconds to allow relaying packages to finish
-		logger.Info("All packets arrived... sleeping 5 seconds")
-		time.Sleep(5 * time.Second)
-		r.sendTrafficReq()
+		r.RunFinished = time.Now()
+		// wait for SettleTime to allow relaying packages to finish, handling other messages in the meantime
+		logger.Infof("All packets arrived... waiting %s", SettleTime)
+		run := r.Runs
+		time.AfterFunc(SettleTime, func() {
+			r.Packets <- &Packet{Event: TaskSettled, Round: run}
+		})
+	}
+}
+
+func (r *Registry) HandleTaskSettled(run int) {
+	if run != r.Runs || !r.StartComplete {
+		// the run has concluded since
+		return
 	}
+	r.sendTrafficReq()
+}
+
+func (r *Registry) HandleTaskProgress(conn net.Conn, msg *pb.MiniChord_TaskProgress) {
+	if !r.tokenIsValid(msg.TaskProgress.GetToken(), msg.TaskProgress.GetId(), "TaskProgress") {
+		return
+	}
+
+	node := r.sessionNode(conn, msg.TaskProgress.GetId(), "TaskProgress")
+	if node == nil {
+		return
+	}
+	if node.State != Running && node.State != Finished {
+		// sent just before the node reported its TrafficSummary
+		logger.Debugf("Ignoring TaskProgress from node %d in state %s", node.Id, node.State)
+		return
+	}
+
+	r.Progress[node.Id] = &Progress{Report: msg.TaskProgress, At: time.Now()}
+}
+
+// Maps a control message to the node that registered on the connection it arrived on.
+// Returns nil, and logs why, if the message claims to come from any other node.
+func (r *Registry) sessionNode(conn net.Conn, id int32, messageType string) *Node {
+	if _, ok := r.Nodes[id]; !ok {
+		logger.Errorf("Rejected %s from %s: node %d is not registered", messageType, conn.RemoteAddr().String(), id)
+		return nil
+	}
+
+	session, ok := r.Sessions[conn]
+	if !ok {
+		logger.Errorf("Rejected %s claiming to be node %d: no node registered on connection %s", messageType, id, conn.RemoteAddr().String())
+		return nil
+	}
+
+	if session.NodeId != id {
+		logger.Errorf("Rejected %s claiming to be node %d: connection %s belongs to node %d", messageType, id, conn.RemoteAddr().String(), session.NodeId)
+		return nil
+	}
+	return r.Nodes[id]
+}
+
+// Moves a node from one state of its lifecycle to the next when a message arrives.
+// Returns false, and logs why, if the node isn't in the state the message belongs to.
+func (r *Registry) advance(node *Node, from NodeState, to NodeState, messageType string) bool {
+	if node.State > from && node.State != Gone {
+		logger.Errorf("Rejected duplicate %s from node %d: node is already %s", messageType, node.Id, node.State)
+		return false
+	}
+	if node.State != from {
+		logger.Errorf("Rejected %s from node %d: node is %s, expected %s", messageType, node.Id, node.State, from)
+		return false
+	}
+
+	logger.Debugf("Node %d: %s -> %s", node.Id, node.State, to)
+	node.State = to
+	return true
+}
+
+// Only nodes holding the token issued to them at registration may send control messages,
+// whether or not a pre-shared key is configured
+func (r *Registry) tokenIsValid(token []byte, id int32, messageType string) bool {
+	node, ok := r.Nodes[id]
+	if !ok {
+		logger.Errorf("Rejected %s from node %d: Node is not registered.", messageType, id)
+		return false
+	}
+	if err := auth.VerifyToken(r.TokenKey, token, id); err != nil {
+		logger.Errorf("Rejected %s from node %d: %s", messageType, id, err.Error())
+		return false
+	}
+	if !hmac.Equal(node.Token, token) {
+		logger.Errorf("Rejected %s from node %d: Session token has been replaced.", messageType, id)
+		return false
+	}
+	return true
 }
 
 func (r *Registry) sendTrafficReq() {
@@ -199,15 +498,27 @@ func (r *Registry) sendTrafficReq() {
 				RequestTrafficSummary: req,
 			},
 		}
+		node.LastRequest = chordMessage
+
 		if err := r.SendMessage(node.Conn, chordMessage); err != nil {
 			errMsg := fmt.Sprintf("Failed to send Traffic Request: %v", err)
 			logger.Error(errMsg)
+			continue
 		}
 		logger.Info(fmt.Sprintf("Succesfully sent Traffic Request to Node (%d) ", node.Id))
 	}
 }
 
-func (r *Registry) HandleTrafficSummary(msg *pb.MiniChord_ReportTrafficSummary) {
+func (r *Registry) HandleTrafficSummary(conn net.Conn, msg *pb.MiniChord_ReportTrafficSummary) {
+	if !r.tokenIsValid(msg.ReportTrafficSummary.GetToken(), msg.ReportTrafficSummary.GetId(), "TrafficSummary") {
+		return
+	}
+
+	node := r.sessionNode(conn, msg.ReportTrafficSummary.GetId(), "TrafficSummary")
+	if node == nil || !r.advance(node, Finished, Reported, "TrafficSummary") {
+		return
+	}
+
 	summary := Summary{
 		Id:            msg.ReportTrafficSummary.GetId(),
 		Sent:          msg.ReportTrafficSummary.GetSent(),
@@ -215,19 +526,129 @@ func (r *Registry) HandleTrafficSummary(msg *pb.MiniChord_ReportTrafficSummary)
 		Relayed:       msg.ReportTrafficSummary.GetRelayed(),
 		TotalSent:     msg.ReportTrafficSummary.GetTotalSent(),
 		TotalReceived: msg.ReportTrafficSummary.GetTotalReceived(),
+		Forged:        msg.ReportTrafficSummary.GetForged(),
+		Hops:          msg.ReportTrafficSummary.GetHops(),
+		Links:         msg.ReportTrafficSummary.GetLinks(),
+		Timeline:      msg.ReportTrafficSummary.GetTimeline(),
+		SentTo:        map[int32]uint32{},
+		ReceivedFrom:  map[int32]uint32{},
+	}
+	for _, flow := range msg.ReportTrafficSummary.GetSentTo() {
+		summary.SentTo[flow.Node] += flow.Packets
+	}
+	for _, flow := range msg.ReportTrafficSummary.GetReceivedFrom() {
+		summary.ReceivedFrom[flow.Node] += flow.Packets
 	}
 
 	r.Summaries = append(r.Summaries, summary)
 
 	if len(r.Keys) == len(r.Summaries) {
-		r.printSummaries()
-		r.Summaries = []Summary{}
-		r.StartComplete = false
+		r.concludeRun()
+	}
+}
+
+// How long relayed packets have to arrive after every node finished sending, before the traffic summaries are requested
+var SettleTime = 5 * time.Second
+
+// How long nodes have to report their TrafficSummary after the task was aborted
+var AbortTimeout = 10 * time.Second
+
+// Stops the running task. Every node that hasn't reported yet is asked for its TrafficSummary
+// by the AbortTask itself, and the run concludes with the summaries that arrive within AbortTimeout.
+func (r *Registry) HandleAbortTask(abort *pb.MiniChord) {
+	if !r.StartComplete {
+		logger.Error("No task is running")
+		return
+	}
+	if r.Aborted {
+		logger.Error("Task already aborted, waiting for traffic summaries")
+		return
+	}
+	if r.NoFinished == len(r.Keys) {
+		logger.Error("Task already finished, waiting for traffic summaries")
+		return
+	}
+	r.Aborted = true
+	if r.Experiment != nil {
+		fmt.Println("Stopping the experiment after this round")
+		r.Experiment.Stopped = true
+	}
+
+	for _, node := range r.Nodes {
+		if node.State != Running && node.State != Finished {
+			continue
+		}
+		node.State = Finished
+		node.LastRequest = abort
+
+		if err := r.SendMessage(node.Conn, abort); err != nil {
+			logger.Errorf("Failed to send AbortTask to node %d: %v", node.Id, err)
+			continue
+		}
+		logger.Infof("Succesfully sent AbortTask to node %d", node.Id)
+	}
+
+	run := r.Runs
+	time.AfterFunc(AbortTimeout, func() {
+		r.Packets <- &Packet{Event: AbortTimedOut, Round: run}
+	})
+}
+
+func (r *Registry) HandleAbortTimedOut(run int) {
+	if run != r.Runs || !r.Aborted {
+		// every node reported in time
+		return
+	}
+	r.concludeRun()
+}
+
+// Prints the summaries of the current task, and returns the nodes to Ready for the next one
+func (r *Registry) concludeRun() {
+	if r.Aborted {
+		fmt.Printf("Task %d was aborted, the traffic summaries below are partial\n", r.Runs)
+	}
+	r.printSummaries()
+	r.Verdict = r.verifyRun()
+	if r.Verdict == Failed {
+		r.FailedRuns++
+	}
+	run := r.newRun()
+	r.History = append(r.History, run)
+	if r.OutputDir != "" {
+		if base, err := exportRun(r.OutputDir, run); err != nil {
+			logger.Errorf("Failed to export task %d: %v", r.Runs, err)
+		} else {
+			fmt.Printf("Task %d exported to %s.json and %s.csv\n", r.Runs, base, base)
+		}
+	}
+	r.Timeline = mergeTimelines(r.Summaries)
+	r.Results = r.Summaries
+
+	for _, node := range r.Nodes {
+		if node.State == Running || node.State == Finished {
+			fmt.Printf("Node %d did not report its TrafficSummary\n", node.Id)
+		}
+		if node.State == Running || node.State == Finished || node.State == Reported {
+			node.State = Ready
+			node.LastRequest = nil
+		}
+	}
+
+	r.Summaries = []Summary{}
+	r.StartComplete = false
+	r.Aborted = false
+
+	r.NoFinished = 0
+
+	if r.Experiment != nil {
+		r.nextExperimentRound(run)
+	} else if r.ExitWhenIdle {
+		r.exitBatch()
 	}
 }
 
 func (r *Registry) printSummaries() {
-	var sentSum, receivedSum uint32
+	var sentSum, receivedSum, forgedSum uint32
 	var totalSentSum, totalReceivedSum int64
 	for _, s := range r.Summaries {
 		fmt.Printf("Node %d,%d,%d,%d,%d,%d\n",
@@ -242,73 +663,328 @@ func (r *Registry) printSummaries() {
 		receivedSum += s.Received
 		totalSentSum += s.TotalSent
 		totalReceivedSum += s.TotalReceived
+		forgedSum += s.Forged
 	}
 	fmt.Printf("Total | %d, %d, %d, %d\n", sentSum, receivedSum, totalSentSum, totalReceivedSum)
+
+	if forgedSum > 0 {
+		for _, s := range r.Summaries {
+			if s.Forged > 0 {
+				fmt.Printf("Node %d dropped %d forged packets\n", s.Id, s.Forged)
+			}
+		}
+		fmt.Printf("Forged | %d\n", forgedSum)
+	}
+
+	printMissingFlows(computeFlows(r.Summaries))
+
+	if r.DataPlane == DatagramDataPlane {
+		r.printLinkLoss()
+	}
 }
 
-// Command Line Handlers
+// Pairs the datagrams each node sent on a link with the datagrams its peer received on it
+func (r *Registry) printLinkLoss() {
+	type linkKey struct{ from, to int32 }
+	sent := map[linkKey]uint32{}
+	received := map[linkKey]*pb.LinkStats{}
 
-func (r *Registry) HandleSetup(routingTableSize int) {
-	if r.SetupSent {
-		logger.Error("Setup already complete")
+	for _, s := range r.Summaries {
+		for _, link := range s.Links {
+			if link.DatagramsSent > 0 {
+				sent[linkKey{s.Id, link.Peer}] = link.DatagramsSent
+			}
+			if link.DatagramsReceived > 0 {
+				received[linkKey{link.Peer, s.Id}] = link
+			}
+		}
+	}
+
+	keys := []linkKey{}
+	for key := range sent {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].from != keys[j].from {
+			return keys[i].from < keys[j].from
+		}
+		return keys[i].to < keys[j].to
+	})
+
+	var sentSum, lostSum, reorderedSum uint32
+	fmt.Println("Link\tSent\tReceived\tLost\tLoss %\tReordered")
+	for _, key := range keys {
+		var receivedCount, reordered uint32
+		if link, ok := received[key]; ok {
+			receivedCount = link.DatagramsReceived
+			reordered = link.DatagramsReordered
+		}
+		lost := sent[key] - min(receivedCount, sent[key])
+		fmt.Printf("%d -> %d\t%d\t%d\t%d\t%.2f\t%d\n",
+			key.from,
+			key.to,
+			sent[key],
+			receivedCount,
+			lost,
+			100*float64(lost)/float64(sent[key]),
+			reordered,
+		)
+		sentSum += sent[key]
+		lostSum += lost
+		reorderedSum += reordered
+	}
+	if sentSum > 0 {
+		fmt.Printf("Datagrams | sent %d, lost %d (%.2f%%), reordered %d\n", sentSum, lostSum, 100*float64(lostSum)/float64(sentSum), reorderedSum)
+	}
+}
+
+func (r *Registry) HandleEvent(packet *Packet) {
+	switch packet.Event {
+	case ConnectionClosed:
+		r.HandleConnectionClosed(packet.Conn)
+	case SetupTimedOut:
+		r.HandleSetupTimedOut(packet.Round)
+	case AbortTimedOut:
+		r.HandleAbortTimedOut(packet.Round)
+	case TaskSettled:
+		r.HandleTaskSettled(packet.Round)
+	case StatsRequested:
+		r.printStats()
+	case TimelineRequested:
+		r.showTimeline(packet.Argument)
+	case LinksRequested:
+		r.printLinkLoad()
+	case FlowsRequested:
+		r.printFlowMatrix()
+	case InputClosed:
+		r.HandleInputClosed()
+	case HistoryRequested:
+		r.printHistory()
+	case CompareRequested:
+		r.compareRuns(packet.Round, packet.Other)
+	case SetupRequested:
+		r.HandleSetupRequested(packet.Round, packet.Argument)
+	case ExperimentRequested:
+		r.HandleExperiment(packet.Argument)
+	case ConditionRequested:
+		packet.Reply <- r.conditionHolds(packet.Argument, packet.Round)
+	case ExportRequested:
+		packet.Reply <- r.exportHistory(packet.Argument)
+	case ShutdownRequested:
+		r.shutdown()
+	case ListRequested:
+		r.printNodes()
+	case RoutesRequested:
+		r.printRoutingTables()
+	case StartRequested:
+		packet.Reply <- r.HandleStartRequested(packet.Round, packet.Argument)
+	default:
+		logger.Errorf("Unknown event: %d", packet.Event)
+	}
+}
+
+// A node whose connection closes before setup is deregistered, as if it had asked to.
+// After setup its Id is baked into the routing tables, so it's only marked as failed, and may still resume its session.
+func (r *Registry) HandleConnectionClosed(conn net.Conn) {
+	delete(r.Challenges, conn)
+
+	session, ok := r.Sessions[conn]
+	if !ok {
+		// never registered, deregistered, or resumed on another connection already
+		return
+	}
+	delete(r.Sessions, conn)
+
+	node := r.Nodes[session.NodeId]
+
+	if !r.SetupSent {
+		node.State = Gone
+		r.RemoveNode(node.Id)
+		logger.Warningf("Node %d disconnected before setup and has been deregistered. The number of messaging nodes currently constituting the overlay is (%d).", node.Id, len(r.Keys))
 		return
 	}
 
-	maxSize := math.Floor(math.Log2(float64(len(r.Nodes))))
+	node.Failed = true
+	logger.Errorf("Node %d disconnected in state %s and is marked as failed.", node.Id, node.State)
+	r.printFailureImpact(node)
+}
 
-	if routingTableSize > int(maxSize) {
-		logger.Warning(fmt.Sprintf("Routing table size %d too large for network size %d. Setting size to maximum: %d", routingTableSize, len(r.Keys), int(maxSize)))
-		routingTableSize = int(maxSize)
+// Prints the latest progress reports of the current task, or of the last one if no task is running
+func (r *Registry) printStats() {
+	if r.Runs == r.LoadedRuns {
+		logger.Error("No task has been started")
+		return
 	}
 
-	r.GenerateRoutingTables(routingTableSize)
+	if r.StartComplete {
+		fmt.Printf("Task %d, running for %s\n", r.Runs, time.Since(r.RunStarted).Round(time.Second))
+	} else {
+		fmt.Printf("Task %d, last progress reported before it ended\n", r.Runs)
+	}
 
-	nodeRegistry := &pb.NodeRegistry{
-		NR:    0,
-		Peers: []*pb.Deregistration{},
-		NoIds: 0,
-		Ids:   []int32{},
+	ids := slices.Clone(r.Keys)
+	slices.Sort(ids)
+
+	var sentSum, relayedSum, receivedSum, queueSum uint32
+	fmt.Println("Node\tState\tSent\tRelayed\tReceived\tQueue\tReported")
+	for _, id := range ids {
+		node := r.Nodes[id]
+		progress, ok := r.Progress[id]
+		if !ok {
+			fmt.Printf("%d\t%s\t-\t-\t-\t-\tnever\n", id, node.State)
+			continue
+		}
+		report := progress.Report
+		fmt.Printf("%d\t%s\t%d\t%d\t%d\t%d\t%s ago\n",
+			id,
+			node.State,
+			report.Sent,
+			report.Relayed,
+			report.Received,
+			report.QueueDepth,
+			time.Since(progress.At).Round(100*time.Millisecond),
+		)
+		sentSum += report.Sent
+		relayedSum += report.Relayed
+		receivedSum += report.Received
+		queueSum += report.QueueDepth
 	}
+	fmt.Printf("Total | %d, %d, %d, %d\n", sentSum, relayedSum, receivedSum, queueSum)
+	fmt.Printf("In flight | %d\n", int64(sentSum)-int64(receivedSum))
+}
 
-	miniChordMsg := &pb.MiniChord{
-		Message: &pb.MiniChord_NodeRegistry{
-			NodeRegistry: nodeRegistry,
-		},
+// Tells the operator what a failed node means for the rest of the overlay
+func (r *Registry) printFailureImpact(failed *Node) {
+	dependents := []int32{}
+	for _, node := range r.Nodes {
+		if _, ok := node.RoutingTable[failed.Id]; ok {
+			dependents = append(dependents, node.Id)
+		}
 	}
+	slices.Sort(dependents)
 
-	packet := &Packet{
-		Conn:    nil,
-		Content: miniChordMsg,
+	fmt.Printf("Node %d has %d neighbours in its routing table, and is in the routing table of nodes %v.\n", failed.Id, len(failed.RoutingTable), dependents)
+	fmt.Printf("Packets destined for node %d, or routed through it, are lost until it resumes its session.\n", failed.Id)
+
+	switch failed.State {
+	case Configured:
+		fmt.Println("The node is left out of the overlay unless it resumes its session before setup times out.")
+	case Ready:
+		fmt.Println("Starting a task now would lose packets, and the task can't finish without the node.")
+	case Running:
+		fmt.Println("The running task can't finish until the node resumes its session and reports TaskFinished.")
+	case Finished:
+		fmt.Println("The node finished its task, but the run can't complete until it reports its TrafficSummary.")
 	}
+}
+
+// Command Line Handlers
 
-	r.Packets <- packet
+func (r *Registry) HandleSetup(routingTableSize int, topology string) {
+	r.Packets <- &Packet{Event: SetupRequested, Round: routingTableSize, Argument: topology}
 }
 
-func (r *Registry) HandleStart(nopackets int) {
-	if !r.SetupComplete {
-		logger.Error("Setup not complete")
+func (r *Registry) HandleSetupRequested(routingTableSize int, topology string) {
+	if r.Experiment != nil {
+		logger.Error("An experiment is running")
 		return
 	}
+	r.setup(routingTableSize, topology)
+}
 
+// Builds the routing tables and sends them out. An overlay that is set up already is set up again,
+// so the same nodes can be compared with other routing tables between tasks.
+func (r *Registry) setup(routingTableSize int, topology string) bool {
 	if r.StartComplete {
-		logger.Error("Start already completed")
-		return
+		logger.Error("A task is running")
+		return false
 	}
+	if r.SetupSent && !r.SetupComplete {
+		logger.Error("Setup already in progress")
+		return false
+	}
+	if !slices.Contains(Topologies, topology) {
+		logger.Errorf("Unknown topology %q, must be one of %s", topology, strings.Join(Topologies, ", "))
+		return false
+	}
+	if len(r.Keys) < 2 {
+		logger.Error("At least 2 nodes must be registered")
+		return false
+	}
+	if routingTableSize < 1 {
+		logger.Error("Routing table size must be positive")
+		return false
+	}
+
+	maxSize := maxRoutingTableSize(topology, len(r.Keys))
+	if routingTableSize > maxSize {
+		logger.Warning(fmt.Sprintf("Routing table size %d too large for network size %d. Setting size to maximum: %d", routingTableSize, len(r.Keys), maxSize))
+		routingTableSize = maxSize
+	}
+
+	r.Topology = topology
+	r.GenerateRoutingTables(routingTableSize)
+	r.HandleNodeRegistry()
+	return true
+}
 
+func (r *Registry) HandleStart(nopackets int, workload string) error {
 	if nopackets < 1 {
-		logger.Error("Number of packets must be positive")
-		return
+		return errors.New("Number of packets must be positive")
+	}
+
+	reply := make(chan bool, 1)
+	r.Packets <- &Packet{Event: StartRequested, Round: nopackets, Argument: workload, Reply: reply}
+	if !<-reply {
+		return errors.New("Task not started")
+	}
+	return nil
+}
+
+// Starts a task if the overlay is ready for one, and logs why not otherwise
+func (r *Registry) HandleStartRequested(nopackets int, workload string) bool {
+	switch {
+	case r.Experiment != nil:
+		logger.Error("An experiment is running")
+		return false
+	case !r.SetupComplete:
+		logger.Error("Setup not complete")
+		return false
+	case r.StartComplete:
+		logger.Error("Start already completed")
+		return false
 	}
 
+	for _, node := range r.Nodes {
+		if node.Failed {
+			logger.Warningf("Node %d is disconnected, packets routed through it will be lost", node.Id)
+		}
+	}
+
+	r.HandleInitiateTask(newInitiateTask(nopackets, workload))
+	return true
+}
+
+func newInitiateTask(nopackets int, workload string) *pb.MiniChord {
 	start := &pb.InitiateTask{
-		Packets: uint32(nopackets),
+		Packets:  uint32(nopackets),
+		Workload: workload,
 	}
 
-	miniChordMsg := &pb.MiniChord{
+	return &pb.MiniChord{
 		Message: &pb.MiniChord_InitiateTask{
 			InitiateTask: start,
 		},
 	}
+}
+
+// Asks for the running task to be aborted, which HandleAbortTask refuses if there is none
+func (r *Registry) HandleStop(drain bool) {
+	miniChordMsg := &pb.MiniChord{
+		Message: &pb.MiniChord_AbortTask{
+			AbortTask: &pb.AbortTask{Drain: drain},
+		},
+	}
 
 	msg := &Packet{
 		Conn:    nil,
@@ -318,7 +994,35 @@ func (r *Registry) HandleStart(nopackets int) {
 	r.Packets <- msg
 }
 
+func (r *Registry) HandleStats() {
+	r.Packets <- &Packet{Event: StatsRequested}
+}
+
+func (r *Registry) HandleTimeline(path string) {
+	r.Packets <- &Packet{Event: TimelineRequested, Argument: path}
+}
+
+func (r *Registry) HandleLinks() {
+	r.Packets <- &Packet{Event: LinksRequested}
+}
+
+func (r *Registry) HandleFlows() {
+	r.Packets <- &Packet{Event: FlowsRequested}
+}
+
+func (r *Registry) HandleHistory() {
+	r.Packets <- &Packet{Event: HistoryRequested}
+}
+
+func (r *Registry) HandleCompare(a int, b int) {
+	r.Packets <- &Packet{Event: CompareRequested, Round: a, Other: b}
+}
+
 func (r *Registry) HandleList() {
+	r.Packets <- &Packet{Event: ListRequested}
+}
+
+func (r *Registry) printNodes() {
 	if len(r.Keys) == 0 {
 		logger.Error("No node is connected to the registry")
 		return
@@ -327,11 +1031,19 @@ func (r *Registry) HandleList() {
 	fmt.Println("-----------------------")
 
 	for _, node := range r.Nodes {
-		fmt.Printf("ID: %d, Address: %s\n", node.Id, node.Address)
+		state := node.State.String()
+		if node.Failed {
+			state += " (disconnected)"
+		}
+		fmt.Printf("ID: %d, Address: %s, State: %s\n", node.Id, node.Address, state)
 	}
 }
 
 func (r *Registry) HandleRouteCmd() {
+	r.Packets <- &Packet{Event: RoutesRequested}
+}
+
+func (r *Registry) printRoutingTables() {
 	if !r.SetupSent {
 		logger.Error("Setup not complete, routing tables have not been calculated")
 		return
@@ -0,0 +1,80 @@
+package registry
+
+import (
+	"net"
+	"testing"
+
+	"github.com/lsig/OverlayNetwork/auth"
+	"github.com/lsig/OverlayNetwork/messages/utils"
+	pb "github.com/lsig/OverlayNetwork/pb"
+	"github.com/lsig/OverlayNetwork/transport"
+)
+
+func TestResumeRejected(t *testing.T) {
+	r, err := NewRegistry("localhost:0", 128, transport.NewPipe())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Listener.Close()
+	r.Nodes[3] = &Node{Id: 3, Address: "127.0.0.1:5003", State: Gone, Token: auth.IssueToken(r.TokenKey, 3)}
+
+	tests := []struct {
+		name  string
+		token []byte
+	}{
+		{name: "unknown node", token: auth.IssueToken(r.TokenKey, 7)},
+		{name: "gone node", token: auth.IssueToken(r.TokenKey, 3)},
+		{name: "other key", token: auth.IssueToken([]byte("0123456789abcdef"), 3)},
+		{name: "malformed", token: []byte("token")},
+		{name: "missing"},
+	}
+
+	for _, test := range tests {
+		conn, other := net.Pipe()
+		responses := make(chan *pb.MiniChord, 1)
+		go func() {
+			message, _ := utils.ReceiveMessage(other)
+			responses <- message
+		}()
+
+		r.resume(conn, &pb.Registration{Address: "127.0.0.1:5003", Token: test.token})
+		if response := (<-responses).GetRegistrationResponse(); response.GetResult() != -1 {
+			t.Errorf("%s: resume answered with %d (%s), want a rejection", test.name, response.GetResult(), response.GetInfo())
+		}
+		conn.Close()
+		other.Close()
+	}
+}
+
+func TestTokenIsValid(t *testing.T) {
+	r, err := NewRegistry("localhost:0", 128, transport.NewPipe())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Listener.Close()
+	// the pre-shared key must not be able to sign tokens
+	r.SharedKey = []byte("0123456789abcdef")
+
+	replaced := auth.IssueToken(r.TokenKey, 3)
+	r.Nodes[3] = &Node{Id: 3, Token: auth.IssueToken(r.TokenKey, 3)}
+
+	tests := []struct {
+		name  string
+		token []byte
+		id    int32
+		valid bool
+	}{
+		{name: "issued", token: r.Nodes[3].Token, id: 3, valid: true},
+		{name: "replaced", token: replaced, id: 3},
+		{name: "other node", token: r.Nodes[3].Token, id: 4},
+		{name: "unknown node", token: auth.IssueToken(r.TokenKey, 7), id: 7},
+		{name: "pre-shared key", token: auth.IssueToken(r.SharedKey, 3), id: 3},
+		{name: "missing", id: 3},
+	}
+
+	for _, test := range tests {
+		if valid := r.tokenIsValid(test.token, test.id, "test"); valid != test.valid {
+			t.Errorf("%s: tokenIsValid = %v, want %v", test.name, valid, test.valid)
+		}
+	}
+}
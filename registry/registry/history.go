@@ -0,0 +1,113 @@
+package registry
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lsig/OverlayNetwork/logger"
+)
+
+// How long the task took until the last node sent TaskFinished,
+// or until the run concluded if it was aborted first
+func (run *Run) CompletionTime() time.Duration {
+	if run.Finished.IsZero() {
+		return run.Ended.Sub(run.Started)
+	}
+	return run.Finished.Sub(run.Started)
+}
+
+func (run *Run) Totals() (sent uint64, received uint64, hops uint64) {
+	for _, s := range run.Summaries {
+		sent += uint64(s.Sent)
+		received += uint64(s.Received)
+		hops += s.Hops
+	}
+	return sent, received, hops
+}
+
+// Packets received per second of completion time
+func (run *Run) Throughput() float64 {
+	_, received, _ := run.Totals()
+	seconds := run.CompletionTime().Seconds()
+	if seconds == 0 {
+		return 0
+	}
+	return float64(received) / seconds
+}
+
+// Mean links a packet that arrived went over
+func (run *Run) PathLength() float64 {
+	_, received, hops := run.Totals()
+	if received == 0 {
+		return 0
+	}
+	return float64(hops)/float64(received) + 1
+}
+
+// Percentage of the packets sent that didn't arrive
+func (run *Run) Loss() float64 {
+	sent, received, _ := run.Totals()
+	if sent == 0 {
+		return 0
+	}
+	return 100 * float64(sent-min(received, sent)) / float64(sent)
+}
+
+func (r *Registry) printHistory() {
+	if len(r.History) == 0 {
+		logger.Error("No task has ended yet")
+		return
+	}
+
+	fmt.Println("Run\tStarted\tNodes\tTable\tPackets\tCompletion\tThroughput\tPath\tLoss %\tVerdict")
+	for _, run := range r.History {
+		fmt.Printf("%d\t%s\t%d\t%d\t%d\t%s\t%.0f/s\t%.2f\t%.2f\t%s\n",
+			run.Run,
+			run.Started.Format(time.TimeOnly),
+			run.Nodes,
+			run.RoutingTableSize,
+			run.Packets,
+			run.CompletionTime().Round(time.Millisecond),
+			run.Throughput(),
+			run.PathLength(),
+			run.Loss(),
+			run.Verdict,
+		)
+	}
+}
+
+// Shows how run b differs from run a
+func (r *Registry) compareRuns(a int, b int) {
+	find := func(number int) *Run {
+		for _, run := range r.History {
+			if run.Run == number {
+				return run
+			}
+		}
+		logger.Errorf("No run %d, see history", number)
+		return nil
+	}
+	first, second := find(a), find(b)
+	if first == nil || second == nil {
+		return
+	}
+
+	delta := func(x float64, y float64) string {
+		if x == 0 {
+			return fmt.Sprintf("%+.2f", y-x)
+		}
+		return fmt.Sprintf("%+.2f (%+.1f%%)", y-x, 100*(y-x)/x)
+	}
+
+	fmt.Printf("Metric\tRun %d\tRun %d\tDelta\n", a, b)
+	fmt.Printf("Nodes\t%d\t%d\t%+d\n", first.Nodes, second.Nodes, second.Nodes-first.Nodes)
+	fmt.Printf("Routing table size\t%d\t%d\t%+d\n", first.RoutingTableSize, second.RoutingTableSize, second.RoutingTableSize-first.RoutingTableSize)
+	fmt.Printf("Topology\t%s\t%s\n", first.Topology, second.Topology)
+	fmt.Printf("Workload\t%s\t%s\n", first.Workload, second.Workload)
+	fmt.Printf("Packets\t%d\t%d\t%+d\n", first.Packets, second.Packets, second.Packets-first.Packets)
+	fmt.Printf("Completion (s)\t%.3f\t%.3f\t%s\n", first.CompletionTime().Seconds(), second.CompletionTime().Seconds(), delta(first.CompletionTime().Seconds(), second.CompletionTime().Seconds()))
+	fmt.Printf("Throughput (packets/s)\t%.0f\t%.0f\t%s\n", first.Throughput(), second.Throughput(), delta(first.Throughput(), second.Throughput()))
+	fmt.Printf("Path length (links)\t%.2f\t%.2f\t%s\n", first.PathLength(), second.PathLength(), delta(first.PathLength(), second.PathLength()))
+	fmt.Printf("Loss %%\t%.2f\t%.2f\t%+.2f\n", first.Loss(), second.Loss(), second.Loss()-first.Loss())
+	fmt.Printf("Verdict\t%s\t%s\n", first.Verdict, second.Verdict)
+}
@@ -0,0 +1,96 @@
+package registry
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/lsig/OverlayNetwork/logger"
+	pb "github.com/lsig/OverlayNetwork/pb"
+)
+
+// Prints the packets every node sent to each of its neighbours during the last task as a matrix,
+// followed by the links ordered by load, so hotspots in the routing tables stand out
+func (r *Registry) printLinkLoad() {
+	if len(r.Results) == 0 {
+		logger.Error("No task has ended yet")
+		return
+	}
+
+	type linkKey struct{ from, to int32 }
+	sent := map[linkKey]*pb.LinkStats{}     // as counted by the sending node
+	received := map[linkKey]*pb.LinkStats{} // as counted by the receiving node
+
+	ids := []int32{}
+	for _, s := range r.Results {
+		ids = append(ids, s.Id)
+		for _, link := range s.Links {
+			if link.PacketsSent > 0 || link.SendErrors > 0 {
+				sent[linkKey{s.Id, link.Peer}] = link
+			}
+			if link.PacketsReceived > 0 {
+				received[linkKey{link.Peer, s.Id}] = link
+			}
+		}
+	}
+	slices.Sort(ids)
+
+	fmt.Print("From\\To")
+	for _, id := range ids {
+		fmt.Printf("\t%d", id)
+	}
+	fmt.Println()
+	for _, from := range ids {
+		fmt.Printf("%d", from)
+		for _, to := range ids {
+			if link, ok := sent[linkKey{from, to}]; ok {
+				fmt.Printf("\t%d", link.PacketsSent)
+			} else {
+				fmt.Print("\t.")
+			}
+		}
+		fmt.Println()
+	}
+
+	keys := []linkKey{}
+	var total uint64
+	for key, link := range sent {
+		keys = append(keys, key)
+		total += uint64(link.PacketsSent)
+	}
+	if len(keys) == 0 {
+		return
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if sent[keys[i]].PacketsSent != sent[keys[j]].PacketsSent {
+			return sent[keys[i]].PacketsSent > sent[keys[j]].PacketsSent
+		}
+		if keys[i].from != keys[j].from {
+			return keys[i].from < keys[j].from
+		}
+		return keys[i].to < keys[j].to
+	})
+	mean := float64(total) / float64(len(keys))
+
+	fmt.Println("Link\tPackets\tBytes\tArrived\tBytes in\tErrors\tMax queue\tLoad")
+	for _, key := range keys {
+		link := sent[key]
+		var arrived uint32
+		var bytesIn uint64
+		if in, ok := received[key]; ok {
+			arrived = in.PacketsReceived
+			bytesIn = in.BytesReceived
+		}
+		fmt.Printf("%d -> %d\t%d\t%d\t%d\t%d\t%d\t%d\t%.2fx\n",
+			key.from,
+			key.to,
+			link.PacketsSent,
+			link.BytesSent,
+			arrived,
+			bytesIn,
+			link.SendErrors,
+			link.MaxQueueDepth,
+			float64(link.PacketsSent)/mean,
+		)
+	}
+}
@@ -0,0 +1,164 @@
+package registry
+
+import (
+	"flag"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/lsig/OverlayNetwork/auth"
+	"github.com/lsig/OverlayNetwork/config"
+	"github.com/lsig/OverlayNetwork/logger"
+	"github.com/lsig/OverlayNetwork/transport"
+)
+
+// Everything the registry can be configured with, by flags or a json config file
+type Config struct {
+	Host         string          `json:"host"`
+	Port         int             `json:"port"`
+	Transport    string          `json:"transport"`
+	DataPlane    string          `json:"dataplane"`
+	TLSCA        string          `json:"tls_ca"`
+	TLSCert      string          `json:"tls_cert"`
+	TLSKey       string          `json:"tls_key"`
+	PSK          string          `json:"psk"`
+	Out          string          `json:"out"`
+	Script       string          `json:"script"`
+	Batch        bool            `json:"batch"`
+	LogLevel     string          `json:"log_level"`
+	QueueSize    int             `json:"queue_size"`
+	SetupTimeout config.Duration `json:"setup_timeout"`
+	SetupRetries int             `json:"setup_retries"`
+	SettleTime   config.Duration `json:"settle_time"`
+	AbortTimeout config.Duration `json:"abort_timeout"`
+	WaitTimeout  config.Duration `json:"wait_timeout"`
+	Seed         uint64          `json:"seed"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Host:         "localhost",
+		Port:         8080,
+		Transport:    "tcp",
+		DataPlane:    "stream",
+		LogLevel:     "info",
+		QueueSize:    128,
+		SetupTimeout: config.Duration{Duration: SetupTimeout},
+		SetupRetries: SetupRetries,
+		SettleTime:   config.Duration{Duration: SettleTime},
+		AbortTimeout: config.Duration{Duration: AbortTimeout},
+		WaitTimeout:  config.Duration{Duration: WaitTimeout},
+	}
+}
+
+// Parses the command line arguments of the registry, reading the config file they name, if any
+func ParseConfig(args []string) (Config, error) {
+	flags := flag.NewFlagSet("registry", flag.ExitOnError)
+
+	cfg := DefaultConfig()
+	flags.StringVar(&cfg.Host, "host", cfg.Host, "host or ip address the registry listens on")
+	flags.IntVar(&cfg.Port, "port", cfg.Port, "port the registry listens on")
+	flags.StringVar(&cfg.Transport, "transport", cfg.Transport, "transport used to reach the nodes: "+strings.Join(transport.Names, ", "))
+	flags.StringVar(&cfg.DataPlane, "dataplane", cfg.DataPlane, "how nodes send NodeData to each other: stream (over the transport) or udp")
+	flags.StringVar(&cfg.TLSCA, "tls-ca", cfg.TLSCA, "CA certificate for mutual tls, see certs/certs.go")
+	flags.StringVar(&cfg.TLSCert, "tls-cert", cfg.TLSCert, "certificate presented to the nodes")
+	flags.StringVar(&cfg.TLSKey, "tls-key", cfg.TLSKey, "private key of the tls certificate")
+	flags.StringVar(&cfg.PSK, "psk", cfg.PSK, "file holding a pre-shared key nodes must prove knowledge of to register")
+	flags.StringVar(&cfg.Out, "out", cfg.Out, "directory every task that ends is exported to, as json and csv")
+	flags.StringVar(&cfg.Script, "script", cfg.Script, "file of commands to run instead of reading them from stdin, see the README")
+	flags.BoolVar(&cfg.Batch, "batch", cfg.Batch, "exit once stdin ends, after the running task, with status 1 if a task failed verification")
+	flags.StringVar(&cfg.LogLevel, "log-level", cfg.LogLevel, "least severe messages logged: "+strings.Join(logger.LevelNames, ", "))
+	flags.IntVar(&cfg.QueueSize, "queue-size", cfg.QueueSize, "messages and commands waiting to be processed before receiving blocks")
+	flags.DurationVar(&cfg.SetupTimeout.Duration, "setup-timeout", cfg.SetupTimeout.Duration, "how long nodes have to answer a NodeRegistry")
+	flags.IntVar(&cfg.SetupRetries, "setup-retries", cfg.SetupRetries, "how often failed nodes are sent the same routing table, before it's rebuilt without them")
+	flags.DurationVar(&cfg.SettleTime.Duration, "settle-time", cfg.SettleTime.Duration, "how long relayed packets have to arrive after every node finished, before summaries are requested")
+	flags.DurationVar(&cfg.AbortTimeout.Duration, "abort-timeout", cfg.AbortTimeout.Duration, "how long nodes have to report after a task was stopped")
+	flags.DurationVar(&cfg.WaitTimeout.Duration, "wait-timeout", cfg.WaitTimeout.Duration, "how long the wait commands of scripts wait by default")
+	flags.Uint64Var(&cfg.Seed, "seed", cfg.Seed, "seed for node ids and random routing tables, 0 picks one at random")
+
+	err := config.Parse(flags, args, &cfg)
+	return cfg, err
+}
+
+// Starts a registry configured by the command line arguments in args, and serves it until the process exits
+func Main(args []string) {
+	cfg, err := ParseConfig(args)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	level, err := logger.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	logger.SetLevel(level)
+
+	if cfg.QueueSize < 1 {
+		logger.Error("The queue size must be positive")
+		os.Exit(1)
+	}
+	SetupTimeout = cfg.SetupTimeout.Duration
+	SetupRetries = cfg.SetupRetries
+	SettleTime = cfg.SettleTime.Duration
+	AbortTimeout = cfg.AbortTimeout.Duration
+	WaitTimeout = cfg.WaitTimeout.Duration
+	if cfg.Seed != 0 {
+		Seed(cfg.Seed)
+	}
+
+	config.Print("Registry", cfg)
+
+	tr, err := transport.Setup(cfg.Transport, cfg.TLSCA, cfg.TLSCert, cfg.TLSKey)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	r, err := NewRegistry(net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)), cfg.QueueSize, tr)
+
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	if cfg.PSK != "" {
+		if r.SharedKey, err = auth.LoadKey(cfg.PSK); err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+	}
+
+	switch cfg.DataPlane {
+	case "stream":
+		r.DataPlane = StreamDataPlane
+	case "udp":
+		r.DataPlane = DatagramDataPlane
+	default:
+		logger.Errorf("unknown data plane %q, must be stream or udp", cfg.DataPlane)
+		os.Exit(1)
+	}
+	// the nodes' datagram sockets are bound to the host and port of their listeners
+	if r.DataPlane == DatagramDataPlane && !transport.UsesIP(tr) {
+		logger.Errorf("The udp data plane can't be used with the %s transport", tr.Name())
+		os.Exit(1)
+	}
+
+	r.Batch = cfg.Batch
+	r.OutputDir = cfg.Out
+	if r.OutputDir != "" {
+		r.LoadHistory(r.OutputDir)
+	}
+
+	go r.Start()
+	if cfg.Script != "" {
+		r.Batch = true
+		go r.RunScript(cfg.Script)
+	} else {
+		go r.CommandLineInterface()
+	}
+
+	select {}
+}
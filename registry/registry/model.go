@@ -3,30 +3,125 @@ package registry
 import (
 	"net"
 	"sync"
+	"time"
 
+	"github.com/lsig/OverlayNetwork/auth"
 	"github.com/lsig/OverlayNetwork/logger"
 	pb "github.com/lsig/OverlayNetwork/pb"
+	"github.com/lsig/OverlayNetwork/transport"
 )
 
+// Where a node is in its lifecycle, as seen by the registry
+type NodeState int
+
+const (
+	Registered NodeState = iota // accepted by the registry
+	Configured                  // NodeRegistry sent
+	Ready                       // connected to its routing table, waiting for a task
+	Running                     // InitiateTask sent
+	Finished                    // TaskFinished received, or the task was aborted
+	Reported                    // TrafficSummary received
+	Gone                        // deregistered
+)
+
+func (s NodeState) String() string {
+	switch s {
+	case Registered:
+		return "Registered"
+	case Configured:
+		return "Configured"
+	case Ready:
+		return "Ready"
+	case Running:
+		return "Running"
+	case Finished:
+		return "Finished"
+	case Reported:
+		return "Reported"
+	case Gone:
+		return "Gone"
+	default:
+		return "Unknown"
+	}
+}
+
 type Node struct {
 	Id           int32
+	State        NodeState
 	Address      string
+	Identity     string // common name of the node's tls certificate, if any
+	PublicKey    []byte // ed25519 key the node signs its packets with, if any
 	RoutingTable map[int32]string
 	Conn         net.Conn
+	Token        []byte                   // session token the node can resume its registration with
+	LastRequest  *pb.MiniChord            // repeated to the node when it resumes, in case it never arrived
+	Failed       bool                     // lost its connection after setup, until it resumes its session
+	SetupFailure *pb.NodeRegistryResponse // the node's answer to its last NodeRegistry, if it couldn't connect to its routing table
 }
 
 func NewNode(id int32, address string, connection net.Conn) *Node {
 	return &Node{
 		Id:           id,
+		State:        Registered,
 		Address:      address,
 		RoutingTable: map[int32]string{},
 		Conn:         connection,
 	}
 }
 
+// Things that happen to the registry without a message being received,
+// passed through the packet channel so they're handled in order with the messages
+type Event int
+
+const (
+	NoEvent             Event = iota
+	ConnectionClosed          // the connection in Packet.Conn was closed
+	SetupTimedOut             // the nodes sent NodeRegistry in Packet.Round had SetupTimeout to answer
+	AbortTimedOut             // the nodes running task Packet.Round had AbortTimeout to report after it was aborted
+	TaskSettled               // every node finished task Packet.Round SettleTime ago
+	StatsRequested            // the operator asked for the progress of the current task
+	TimelineRequested         // the operator asked for the timeline of the last task, exported to Packet.Argument if set
+	LinksRequested            // the operator asked for the link load of the last task
+	FlowsRequested            // the operator asked for the loss per source and destination of the last task
+	InputClosed               // the operator's input ended, which in batch mode means exiting
+	HistoryRequested          // the operator asked for the runs so far
+	CompareRequested          // the operator asked how run Packet.Other differs from run Packet.Round
+	SetupRequested            // the operator asked for routing tables of size Packet.Round, in topology Packet.Argument
+	ExperimentRequested       // the operator asked for the sweep in Packet.Argument
+	ConditionRequested        // a script waits for condition Packet.Argument, answered on Packet.Reply
+	ExportRequested           // a script asked for the runs so far to be exported to Packet.Argument, answered on Packet.Reply
+	ShutdownRequested         // the operator asked for the nodes and the registry to exit
+	ListRequested             // the operator asked for the registered nodes
+	RoutesRequested           // the operator asked for the routing tables
+	StartRequested            // the operator asked for a task of Packet.Round packets per node in workload Packet.Argument, answered on Packet.Reply
+)
+
 type Packet struct {
-	Conn    net.Conn
-	Content *pb.MiniChord
+	Conn     net.Conn
+	Content  *pb.MiniChord
+	Event    Event
+	Round    int
+	Other    int
+	Argument string
+	Reply    chan bool // whether the event succeeded, for events a script waits on
+}
+
+// A registration waiting for the node to answer its authentication challenge
+type Challenge struct {
+	Nonce        []byte
+	Registration *pb.Registration
+}
+
+// The latest progress report of a node during the current task
+type Progress struct {
+	Report *pb.TaskProgress
+	At     time.Time
+}
+
+// The node registered on a connection
+type Session struct {
+	Conn   net.Conn
+	NodeId int32
 }
 
 type Registry struct {
@@ -34,25 +129,55 @@ type Registry struct {
 	IdSpace       []int32
 	Keys          []int32
 	RTableSize    int
+	Topology      string
+	DataPlane     string
+	SharedKey     []byte
+	TokenKey      []byte // signs session tokens, random and never shared with the nodes
+	Challenges    map[net.Conn]*Challenge
+	Sessions      map[net.Conn]*Session
 	SetupSent     bool
 	SetupComplete bool
+	SetupRound    int // incremented every time NodeRegistry is sent out, so timeouts of earlier rounds are ignored
+	SetupAttempt  int // retries of the current routing tables
 	StartComplete bool
+	Runs          int       // tasks started so far, which numbers the current one
+	LoadedRuns    int       // the highest run read from the output directory, this process numbers its tasks on from it
+	RunStarted    time.Time // when the current task was started
+	RunFinished   time.Time // when every node of the current task sent TaskFinished
+	Workload      string    // of the current task
+	Progress      map[int32]*Progress
+	Timeline      []Second  // of the last task that ended, summed over all nodes
+	Results       []Summary // of the last task that ended
+	History       []*Run    // every task that ended
+	Aborted       bool      // the current task was stopped before every node finished
+	Verdict       Verdict   // of the last task that ended
+	FailedRuns    int       // tasks that failed verification
+	Batch         bool      // exit once the input ends, with a non-zero status if a task failed verification
+	ExitWhenIdle  bool      // the input ended while a task was running
+	OutputDir     string    // every task that ends is exported here, if set
+	Experiment    *Experiment
 	NoPackets     int
-	NoSetupNodes  int
 	NoFinished    int
 	Summaries     []Summary
+	Transport     transport.Transport
 	Listener      net.Listener
 	Packets       chan *Packet
 	Locker        sync.Mutex
 }
 
-func NewRegistry(port string) (*Registry, error) {
-	listener, err := net.Listen("tcp", "localhost:"+port)
+// Creates a registry listening on address, which queues up to queueSize messages and commands for processing
+func NewRegistry(address string, queueSize int, tr transport.Transport) (*Registry, error) {
+	listener, err := tr.Listen(address)
 	if err != nil {
 		logger.Error("Failed to initilize listener")
 		return nil, err
 	}
 
+	tokenKey, err := auth.NewKey()
+	if err != nil {
+		return nil, err
+	}
+
 	idSpace := []int32{}
 
 	for i := range 128 {
@@ -64,16 +189,54 @@ func NewRegistry(port string) (*Registry, error) {
 		IdSpace:       idSpace,
 		Keys:          []int32{},
 		RTableSize:    0,
+		Topology:      ChordTopology,
+		TokenKey:      tokenKey,
+		Challenges:    map[net.Conn]*Challenge{},
+		Sessions:      map[net.Conn]*Session{},
+		Progress:      map[int32]*Progress{},
 		SetupSent:     false,
 		SetupComplete: false,
 		StartComplete: false,
 		NoPackets:     0,
-		NoSetupNodes:  0,
+		Transport:     tr,
 		Listener:      listener,
-		Packets:       make(chan *Packet, 128),
+		Packets:       make(chan *Packet, queueSize),
 	}, nil
 }
 
+const (
+	StreamDataPlane   = ""
+	DatagramDataPlane = "udp"
+)
+
+// How the routing tables are built. Every node's first neighbour is its successor on the ring of ids,
+// which the nodes' greedy routing relies on, the topology decides the others.
+const (
+	ChordTopology  = "chord"  // neighbours at distances 1, 2, 4, ... on the ring
+	RingTopology   = "ring"   // the next nodes on the ring
+	RandomTopology = "random" // the successor and nodes picked at random
+)
+
+var Topologies = []string{ChordTopology, RingTopology, RandomTopology}
+
+// How nodes pick the destinations of their packets, as sent in InitiateTask
+const (
+	UniformWorkload = ""        // every other node is equally likely
+	HotspotWorkload = "hotspot" // half the packets go to the node with the lowest id
+)
+
+// Names the operator picks workloads by
+var Workloads = map[string]string{"uniform": UniformWorkload, "hotspot": HotspotWorkload}
+
+func workloadName(workload string) string {
+	for name, w := range Workloads {
+		if w == workload {
+			return name
+		}
+	}
+	return workload
+}
+
 type Summary struct {
 	Id            int32
 	Sent          uint32
@@ -81,4 +244,17 @@ type Summary struct {
 	Relayed       uint32
 	TotalSent     int64
 	TotalReceived int64
+	Forged        uint32
+	Links         []*pb.LinkStats
+	Timeline      []*pb.TimelineSecond
+	SentTo        map[int32]uint32 // packets created, per destination
+	ReceivedFrom  map[int32]uint32 // packets arrived, per source
+	Hops          uint64           // relays the packets that arrived went through
+}
+
+// Packets sent, relayed and received during a single second of a task
+type Second struct {
+	Sent     uint32
+	Relayed  uint32
+	Received uint32
 }
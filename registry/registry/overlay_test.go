@@ -0,0 +1,99 @@
+package registry
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lsig/OverlayNetwork/logger"
+	"github.com/lsig/OverlayNetwork/messages/helpers"
+	"github.com/lsig/OverlayNetwork/messages/utils"
+	"github.com/lsig/OverlayNetwork/transport"
+)
+
+// The nodes of the overlay tests keep running once a test is done, so these are set once for all of them
+func TestMain(m *testing.M) {
+	logger.SetLevel(logger.ErrorLevel)
+	SettleTime = 100 * time.Millisecond
+	os.Exit(m.Run())
+}
+
+// Runs a node in this process the way helpers.Main does, until the registry goes away
+func runNode(tr transport.Transport, registryAddress string) error {
+	registry, err := utils.GetRegistryFromProgramArgs([]string{registryAddress})
+	if err != nil {
+		return err
+	}
+	node, err := helpers.CreateListenerNode(tr, "localhost", "localhost", 0)
+	if err != nil {
+		return err
+	}
+	if err := helpers.ConnectToRegistry(node, registry); err != nil {
+		return err
+	}
+	response, err := helpers.Register(node, registry)
+	if err != nil {
+		return err
+	}
+	node.Id = response.Result
+	go helpers.HandleRegistry(node, registry)
+
+	var wg sync.WaitGroup
+	network := helpers.NewNetwork(128)
+	wg.Add(1)
+	go helpers.HandleConnector(&wg, node, network)
+	return helpers.HandleRegistryRequests(&wg, node, network, registry)
+}
+
+// Sets up a registry and nodes over the pipe transport in this process, and runs a task on them
+func TestOverlayOverPipes(t *testing.T) {
+	const nodes = 5
+	const packets = 200
+
+	tr := transport.SharedPipe
+	r, err := NewRegistry("localhost:0", 128, tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go r.Start()
+
+	failures := make(chan error, nodes)
+	for range nodes {
+		go func() {
+			failures <- runNode(tr, r.Listener.Addr().String())
+		}()
+	}
+
+	commands := []string{fmt.Sprintf("wait-nodes %d 10s", nodes), "setup 2", "wait-ready 10s", fmt.Sprintf("start %d", packets), "wait-summaries 30s"}
+	for _, command := range commands {
+		if err := r.Execute(command); err != nil {
+			select {
+			case failure := <-failures:
+				t.Fatalf("%s: %v, a node failed: %v", command, err, failure)
+			default:
+				t.Fatalf("%s: %v", command, err)
+			}
+		}
+	}
+
+	// the wait for the summaries was answered by the goroutine that recorded the run, after it did
+	if len(r.History) != 1 {
+		t.Fatalf("%d runs recorded, want 1", len(r.History))
+	}
+	run := r.History[0]
+	if run.Verdict != Passed.String() {
+		t.Errorf("verdict %s, want %s", run.Verdict, Passed)
+	}
+	if run.Nodes != nodes || len(run.Summaries) != nodes {
+		t.Errorf("%d nodes and %d summaries, want %d each", run.Nodes, len(run.Summaries), nodes)
+	}
+	sent, received, _ := run.Totals()
+	if sent != nodes*packets || received != sent {
+		t.Errorf("%d packets sent and %d received, want %d each", sent, received, nodes*packets)
+	}
+	if run.Transport != "pipe" {
+		t.Errorf("transport %s, want pipe", run.Transport)
+	}
+}
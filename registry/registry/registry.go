@@ -3,12 +3,14 @@ package registry
 import (
 	"bufio"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/lsig/OverlayNetwork/logger"
 	pb "github.com/lsig/OverlayNetwork/pb"
@@ -36,51 +38,125 @@ func (r *Registry) Start() {
 func (r *Registry) CommandLineInterface() {
 	scanner := bufio.NewScanner(os.Stdin)
 	for scanner.Scan() {
-		command := strings.TrimSpace(scanner.Text())
-		switch {
-		case command == "list":
-			r.HandleList()
-		case command == "route":
-			r.HandleRouteCmd()
-		case strings.HasPrefix(command, "setup "):
-			param := strings.TrimPrefix(command, "setup ")
-			n, err := strconv.Atoi(param)
-			if err != nil {
-				logger.Error("Invalid number of nodes:" + param)
-				continue
-			}
-			r.HandleSetup(n)
-		case strings.HasPrefix(command, "start "):
-			param := strings.TrimPrefix(command, "start ")
-			n, err := strconv.Atoi(param)
-			if err != nil {
-				logger.Error("Invalid number of Packets:" + param)
-				continue
-			}
-			r.HandleStart(n)
+		if err := r.Execute(strings.TrimSpace(scanner.Text())); err != nil {
+			logger.Error(err.Error())
 		}
 	}
 
+	if r.Batch {
+		r.Packets <- &Packet{Event: InputClosed}
+	}
+}
+
+// Runs a command of the operator. It fails if the command is malformed, or if it could tell right away that it can't be done,
+// otherwise its outcome is reported by the goroutine processing the messages.
+func (r *Registry) Execute(command string) error {
+	switch {
+	case command == "":
+		return nil
+	case command == "list":
+		r.HandleList()
+	case command == "route":
+		r.HandleRouteCmd()
+	case strings.HasPrefix(command, "setup "):
+		params := strings.Fields(strings.TrimPrefix(command, "setup "))
+		n, err := strconv.Atoi(params[0])
+		if err != nil || len(params) > 2 {
+			return errors.New("Invalid number of nodes:" + strings.Join(params, " "))
+		}
+		topology := ChordTopology
+		if len(params) == 2 {
+			topology = params[1]
+		}
+		r.HandleSetup(n, topology)
+	case strings.HasPrefix(command, "start "):
+		params := strings.Fields(strings.TrimPrefix(command, "start "))
+		n, err := strconv.Atoi(params[0])
+		if err != nil || len(params) > 2 {
+			return errors.New("Invalid number of Packets:" + strings.Join(params, " "))
+		}
+		workload := UniformWorkload
+		if len(params) == 2 {
+			var ok bool
+			if workload, ok = Workloads[params[1]]; !ok {
+				return errors.New("Unknown workload:" + params[1])
+			}
+		}
+		return r.HandleStart(n, workload)
+	case strings.HasPrefix(command, "experiment"):
+		r.HandleExperimentCmd(strings.TrimSpace(strings.TrimPrefix(command, "experiment")))
+	case command == "stats":
+		r.HandleStats()
+	case command == "timeline":
+		r.HandleTimeline("")
+	case strings.HasPrefix(command, "timeline "):
+		r.HandleTimeline(strings.TrimSpace(strings.TrimPrefix(command, "timeline ")))
+	case command == "links":
+		r.HandleLinks()
+	case command == "flows":
+		r.HandleFlows()
+	case command == "history":
+		r.HandleHistory()
+	case strings.HasPrefix(command, "compare "):
+		params := strings.Fields(strings.TrimPrefix(command, "compare "))
+		if len(params) != 2 {
+			return errors.New("Usage: compare <run> <run>")
+		}
+		a, errA := strconv.Atoi(params[0])
+		b, errB := strconv.Atoi(params[1])
+		if errA != nil || errB != nil {
+			return errors.New("Invalid run numbers:" + strings.Join(params, " "))
+		}
+		r.HandleCompare(a, b)
+	case command == "stop":
+		r.HandleStop(false)
+	case command == "stop drain":
+		r.HandleStop(true)
+	case strings.HasPrefix(command, "wait-"):
+		return r.HandleWait(command)
+	case strings.HasPrefix(command, "sleep "):
+		duration, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(command, "sleep ")))
+		if err != nil {
+			return fmt.Errorf("Invalid duration: %v", err)
+		}
+		time.Sleep(duration)
+	case command == "export" || strings.HasPrefix(command, "export "):
+		return r.HandleExport(strings.TrimSpace(strings.TrimPrefix(command, "export")))
+	case command == "shutdown":
+		r.HandleShutdown()
+	default:
+		return fmt.Errorf("Unknown command: %s", command)
+	}
+	return nil
 }
 
 func (r *Registry) MessageProcessing() {
 	go func() {
 		for packet := range r.Packets {
+			if packet.Event != NoEvent {
+				r.HandleEvent(packet)
+				continue
+			}
+
 			switch msg := packet.Content.Message.(type) {
 			case *pb.MiniChord_Registration:
 				r.HandleRegistration(packet.Conn, msg)
+			case *pb.MiniChord_AuthResponse:
+				r.HandleAuthResponse(packet.Conn, msg)
 			case *pb.MiniChord_Deregistration:
 				r.HandleDeregistration(packet.Conn, msg)
-			case *pb.MiniChord_NodeRegistry:
-				r.HandleNodeRegistry()
 			case *pb.MiniChord_NodeRegistryResponse:
-				r.HandleNodeRegistryResponse(msg)
+				r.HandleNodeRegistryResponse(packet.Conn, msg)
 			case *pb.MiniChord_InitiateTask:
 				r.HandleInitiateTask(packet.Content)
+			case *pb.MiniChord_AbortTask:
+				r.HandleAbortTask(packet.Content)
 			case *pb.MiniChord_TaskFinished:
 				r.HandleTaskFinished(packet.Conn, msg)
 			case *pb.MiniChord_ReportTrafficSummary:
-				r.HandleTrafficSummary(msg)
+				r.HandleTrafficSummary(packet.Conn, msg)
+			case *pb.MiniChord_TaskProgress:
+				r.HandleTaskProgress(packet.Conn, msg)
 			default:
 				errMsg := fmt.Sprintf("Unknown message type received: %s", msg)
 				logger.Error(errMsg)
@@ -154,25 +230,15 @@ func (r *Registry) HandleConnection(conn net.Conn) {
 	for {
 		err := r.ReceiveMessage(conn)
 		if err != nil {
-			if err != io.EOF {
+			// the registry closes the connections of nodes it leaves out of the overlay itself
+			if err != io.EOF && !errors.Is(err, net.ErrClosed) {
 				msg := fmt.Sprintf("Error receiving message: %v", err)
 				logger.Error(msg)
 			}
 			break
 		}
 	}
-}
-
-func main() {
-	r, err := NewRegistry("8080")
-
-	if err != nil {
-		logger.Error(err.Error())
-		os.Exit(1)
-	}
-
-	go r.Start()
-	go r.CommandLineInterface()
 
-	select {}
+	// let the message processing find out which node, if any, was on the other end
+	r.Packets <- &Packet{Conn: conn, Event: ConnectionClosed}
 }
@@ -0,0 +1,181 @@
+package registry
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lsig/OverlayNetwork/logger"
+	pb "github.com/lsig/OverlayNetwork/pb"
+)
+
+// How long the wait commands wait unless the script says otherwise
+var WaitTimeout = 5 * time.Minute
+
+// How often the wait commands check whether they're done
+const waitInterval = 100 * time.Millisecond
+
+// The exit status of a script that failed, as opposed to a task that failed verification
+const ScriptFailed = 2
+
+// What the wait commands wait for
+const (
+	NodesCondition     = "nodes"     // at least Packet.Round nodes registered
+	ReadyCondition     = "ready"     // the overlay is set up, and no task is running
+	SummariesCondition = "summaries" // no task or experiment is running, so the last one has reported
+)
+
+// Runs the commands in a script file one after the other, as if the operator typed them.
+// Blank lines and lines starting with # are skipped. The registry exits with ScriptFailed at the first command that fails,
+// and as in batch mode once the script ends.
+func (r *Registry) RunScript(path string) {
+	file, err := os.Open(path)
+	if err != nil {
+		logger.Errorf("Failed to open script: %v", err)
+		os.Exit(ScriptFailed)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+		command := strings.TrimSpace(scanner.Text())
+		if command == "" || strings.HasPrefix(command, "#") {
+			continue
+		}
+
+		fmt.Printf("%s:%d: %s\n", path, line, command)
+		if err := r.Execute(command); err != nil {
+			logger.Errorf("Script failed at %s:%d: %v", path, line, err)
+			os.Exit(ScriptFailed)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		logger.Errorf("Failed to read script: %v", err)
+		os.Exit(ScriptFailed)
+	}
+
+	r.Packets <- &Packet{Event: InputClosed}
+}
+
+// Parses wait-nodes <n> [timeout], wait-ready [timeout] and wait-summaries [timeout], and waits until the condition holds
+func (r *Registry) HandleWait(command string) error {
+	params := strings.Fields(command)
+	name := params[0]
+	condition := strings.TrimPrefix(name, "wait-")
+	params = params[1:]
+
+	n := 0
+	switch condition {
+	case NodesCondition:
+		if len(params) == 0 {
+			return fmt.Errorf("Usage: wait-nodes <n> [timeout]")
+		}
+		var err error
+		if n, err = strconv.Atoi(params[0]); err != nil || n < 1 {
+			return fmt.Errorf("Invalid number of nodes: %s", params[0])
+		}
+		params = params[1:]
+	case ReadyCondition, SummariesCondition:
+	default:
+		return fmt.Errorf("Unknown command: %s", command)
+	}
+
+	timeout := WaitTimeout
+	if len(params) > 1 {
+		return fmt.Errorf("Too many arguments: %s", command)
+	}
+	if len(params) == 1 {
+		var err error
+		if timeout, err = time.ParseDuration(params[0]); err != nil {
+			return fmt.Errorf("Invalid timeout: %v", err)
+		}
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		reply := make(chan bool, 1)
+		// goes through the packet channel, so it's answered after the commands before it were handled
+		r.Packets <- &Packet{Event: ConditionRequested, Argument: condition, Round: n, Reply: reply}
+		if <-reply {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s timed out after %s", name, timeout)
+		}
+		time.Sleep(waitInterval)
+	}
+}
+
+func (r *Registry) conditionHolds(condition string, n int) bool {
+	switch condition {
+	case NodesCondition:
+		return len(r.Keys) >= n
+	case ReadyCondition:
+		return r.SetupComplete && !r.StartComplete && r.Experiment == nil
+	case SummariesCondition:
+		return !r.StartComplete && r.Experiment == nil
+	default:
+		return false
+	}
+}
+
+// Exports every run so far to dir, or to the output directory if dir is empty
+func (r *Registry) HandleExport(dir string) error {
+	if dir == "" {
+		dir = r.OutputDir
+	}
+	if dir == "" {
+		return fmt.Errorf("Usage: export <dir>, unless the registry was started with -out")
+	}
+
+	reply := make(chan bool, 1)
+	r.Packets <- &Packet{Event: ExportRequested, Argument: dir, Reply: reply}
+	if !<-reply {
+		return fmt.Errorf("Export to %s failed", dir)
+	}
+	return nil
+}
+
+func (r *Registry) exportHistory(dir string) bool {
+	if len(r.History) == 0 {
+		logger.Error("No task has ended yet")
+		return false
+	}
+	for _, run := range r.History {
+		if _, err := exportRun(dir, run); err != nil {
+			logger.Errorf("Failed to export task %d: %v", run.Run, err)
+			return false
+		}
+	}
+	fmt.Printf("%d runs exported to %s\n", len(r.History), dir)
+	return true
+}
+
+func (r *Registry) HandleShutdown() {
+	r.Packets <- &Packet{Event: ShutdownRequested}
+}
+
+// Tells every node to exit, and exits with the status batch mode would
+func (r *Registry) shutdown() {
+	shutdown := &pb.MiniChord{
+		Message: &pb.MiniChord_Shutdown{
+			Shutdown: &pb.Shutdown{},
+		},
+	}
+	for _, node := range r.Nodes {
+		if node.Failed {
+			continue
+		}
+		if err := r.SendMessage(node.Conn, shutdown); err != nil {
+			logger.Errorf("Failed to send Shutdown to node %d: %v", node.Id, err)
+			continue
+		}
+		logger.Infof("Succesfully sent Shutdown to node %d", node.Id)
+	}
+	r.exitBatch()
+}
@@ -0,0 +1,188 @@
+package registry
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/lsig/OverlayNetwork/logger"
+	pb "github.com/lsig/OverlayNetwork/pb"
+)
+
+// How long nodes have to answer a NodeRegistry before setup goes on without them
+var SetupTimeout = 10 * time.Second
+
+// How often nodes that failed their setup are sent the same routing table again,
+// before the routing tables are rebuilt without the nodes to blame
+var SetupRetries = 1
+
+// Sends the given nodes their routing tables, and arms the timeout for their answers
+func (r *Registry) sendNodeRegistry(ids []int32) {
+	identities := []*pb.NodeIdentity{}
+	for _, node := range r.Nodes {
+		identities = append(identities, &pb.NodeIdentity{Id: node.Id, Address: node.Address, PublicKey: node.PublicKey, Name: node.Identity})
+	}
+
+	for _, id := range ids {
+		node := r.Nodes[id]
+
+		peers := []*pb.Deregistration{}
+		for key, val := range node.RoutingTable {
+			info := &pb.Deregistration{
+				Id:      key,
+				Address: val,
+			}
+			peers = append(peers, info)
+		}
+		nodeRegistry := &pb.NodeRegistry{
+			NR:        uint32(len(node.RoutingTable)),
+			NoIds:     uint32(len(r.Keys)),
+			Peers:     peers,
+			Ids:       r.Keys,
+			DataPlane: r.DataPlane,
+			Nodes:     identities,
+		}
+		chordMessage := &pb.MiniChord{
+			Message: &pb.MiniChord_NodeRegistry{
+				NodeRegistry: nodeRegistry,
+			},
+		}
+
+		node.State = Configured
+		node.SetupFailure = nil
+		node.LastRequest = chordMessage
+
+		if err := r.SendMessage(node.Conn, chordMessage); err != nil {
+			errMsg := fmt.Sprintf("Failed to send NodeRegistry request: %v", err)
+			logger.Error(errMsg)
+			continue
+		}
+		logger.Info(fmt.Sprintf("Succesfully sent NodeRegistry to node %d", node.Id))
+	}
+
+	r.SetupRound++
+	round := r.SetupRound
+	time.AfterFunc(SetupTimeout, func() {
+		r.Packets <- &Packet{Event: SetupTimedOut, Round: round}
+	})
+}
+
+func (r *Registry) HandleSetupTimedOut(round int) {
+	if round != r.SetupRound || !r.SetupSent || r.SetupComplete {
+		// every node answered in time, or setup has moved on since
+		return
+	}
+	r.concludeSetup()
+}
+
+// Reports the outcome of a setup round to the operator, and decides what to do about the nodes that failed it:
+// they're retried first, and if that doesn't help, the routing tables are rebuilt without them.
+func (r *Registry) concludeSetup() {
+	// no timeout of this round must conclude it again
+	r.SetupRound++
+
+	ready, failed, silent := []int32{}, []int32{}, []int32{}
+	slices.Sort(r.Keys)
+	for _, id := range r.Keys {
+		node := r.Nodes[id]
+		switch {
+		case node.State == Ready:
+			ready = append(ready, id)
+		case node.SetupFailure != nil:
+			failed = append(failed, id)
+		default:
+			silent = append(silent, id)
+		}
+	}
+
+	fmt.Printf("Setup report (%s topology, routing table size %d, attempt %d):\n", r.Topology, r.RTableSize, r.SetupAttempt+1)
+	fmt.Printf("Ready: %d/%d nodes %v\n", len(ready), len(r.Keys), ready)
+	for _, id := range failed {
+		failure := r.Nodes[id].SetupFailure
+		if len(failure.GetUnreachable()) > 0 {
+			fmt.Printf("Node %d failed to connect to nodes %v\n", id, failure.GetUnreachable())
+		} else {
+			fmt.Printf("Node %d failed: %s\n", id, failure.GetInfo())
+		}
+	}
+	for _, id := range silent {
+		fmt.Printf("Node %d did not answer within %s\n", id, SetupTimeout)
+	}
+
+	if len(failed) == 0 && len(silent) == 0 {
+		fmt.Println("Setup complete.")
+		logger.Info("The registry is now ready to initiate tasks.")
+		r.SetupComplete = true
+		if r.Experiment != nil {
+			r.startExperimentTask()
+		}
+		return
+	}
+
+	if r.SetupAttempt < SetupRetries {
+		r.SetupAttempt++
+		retry := append(failed, silent...)
+		fmt.Printf("Retrying setup of nodes %v\n", retry)
+		r.sendNodeRegistry(retry)
+		return
+	}
+
+	excluded := r.blameSetupFailures(ready, failed, silent)
+	fmt.Printf("Rebuilding routing tables without nodes %v\n", excluded)
+	for _, id := range excluded {
+		node := r.Nodes[id]
+		node.State = Gone
+		r.RemoveNode(id)
+		// the node finds out when it tries to resume its session
+		node.Conn.Close()
+	}
+
+	if len(r.Keys) < 2 {
+		fmt.Printf("Setup failed: %d node(s) left in the overlay. Register more nodes and run setup again.\n", len(r.Keys))
+		r.SetupSent = false
+		if r.Experiment != nil {
+			r.endExperiment()
+		}
+		return
+	}
+
+	size := min(r.RTableSize, maxRoutingTableSize(r.Topology, len(r.Keys)))
+	r.GenerateRoutingTables(size)
+	r.SetupAttempt = 0
+	r.sendNodeRegistry(r.Keys)
+}
+
+// Picks the nodes to leave out of the overlay after setup failed repeatedly.
+// Silent nodes are left out, and so are nodes their neighbours couldn't connect to,
+// unless a node that is ready did connect to them, in which case the node reporting them is to blame.
+func (r *Registry) blameSetupFailures(ready []int32, failed []int32, silent []int32) []int32 {
+	reachable := map[int32]bool{}
+	for _, id := range ready {
+		for peer := range r.Nodes[id].RoutingTable {
+			reachable[peer] = true
+		}
+	}
+
+	excluded := slices.Clone(silent)
+	for _, id := range failed {
+		blamed := false
+		for _, peer := range r.Nodes[id].SetupFailure.GetUnreachable() {
+			if !reachable[peer] {
+				excluded = append(excluded, peer)
+				blamed = true
+			}
+		}
+		if !blamed {
+			excluded = append(excluded, id)
+		}
+	}
+
+	slices.Sort(excluded)
+	excluded = slices.Compact(excluded)
+
+	// a node may report a neighbour that has been removed from the overlay since
+	return slices.DeleteFunc(excluded, func(id int32) bool {
+		_, ok := r.Nodes[id]
+		return !ok
+	})
+}
@@ -0,0 +1,71 @@
+package registry
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/lsig/OverlayNetwork/logger"
+)
+
+// Sums the per-second counters of all nodes into the timeline of the whole overlay.
+// Every node counts from the moment it started the task, so seconds line up to within the time the InitiateTask took to arrive.
+func mergeTimelines(summaries []Summary) []Second {
+	timeline := []Second{}
+	for _, s := range summaries {
+		for i, second := range s.Timeline {
+			for len(timeline) <= i {
+				timeline = append(timeline, Second{})
+			}
+			timeline[i].Sent += second.GetSent()
+			timeline[i].Relayed += second.GetRelayed()
+			timeline[i].Received += second.GetReceived()
+		}
+	}
+	return timeline
+}
+
+// Prints the timeline of the last task, or writes it to a csv file if a path is given
+func (r *Registry) showTimeline(path string) {
+	if len(r.Timeline) == 0 {
+		logger.Error("No task has ended yet")
+		return
+	}
+
+	if path != "" {
+		if err := writeTimelineCSV(path, r.Timeline); err != nil {
+			logger.Errorf("Failed to export timeline: %v", err)
+			return
+		}
+		fmt.Printf("Timeline of %d seconds written to %s\n", len(r.Timeline), path)
+		return
+	}
+
+	fmt.Println("Second\tSent\tRelayed\tReceived")
+	for i, second := range r.Timeline {
+		fmt.Printf("%d\t%d\t%d\t%d\n", i, second.Sent, second.Relayed, second.Received)
+	}
+}
+
+// Writes one row per second, below a second,sent,relayed,received header
+func writeTimelineCSV(path string, timeline []Second) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"second", "sent", "relayed", "received"})
+	for i, second := range timeline {
+		writer.Write([]string{
+			strconv.Itoa(i),
+			strconv.FormatUint(uint64(second.Sent), 10),
+			strconv.FormatUint(uint64(second.Relayed), 10),
+			strconv.FormatUint(uint64(second.Received), 10),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -11,6 +11,15 @@ import (
 	"github.com/lsig/OverlayNetwork/logger"
 )
 
+// Source of the node ids and random routing tables, only used by the processing goroutine
+var random = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+
+// Makes the node ids and random routing tables the same for every registry started with seed,
+// given the nodes register in the same order
+func Seed(seed uint64) {
+	random = rand.New(rand.NewPCG(seed, seed))
+}
+
 func (r *Registry) AddNode(address string, connection net.Conn) int32 {
 	r.Locker.Lock()
 	defer r.Locker.Unlock()
@@ -32,9 +41,10 @@ func (r *Registry) AddNode(address string, connection net.Conn) int32 {
 func (r *Registry) RemoveNode(id int32) int32 {
 	r.Locker.Lock()
 	defer r.Locker.Unlock()
-	_, ok := r.Nodes[id]
+	node, ok := r.Nodes[id]
 	if ok {
 		delete(r.Nodes, id)
+		delete(r.Sessions, node.Conn)
 		r.Keys = deleteKey(r.Keys, id)
 
 		msg := fmt.Sprintf("Node %d removed from overlay network", id)
@@ -54,9 +64,13 @@ func (r *Registry) GenerateRoutingTables(size int) {
 	slices.Sort(r.Keys)
 	noKeys := len(r.Keys)
 
+	// tables are regenerated when setup fails, so start from scratch
+	for _, node := range r.Nodes {
+		node.RoutingTable = map[int32]string{}
+	}
+
 	for index, key := range r.Keys {
-		for i := range size {
-			neighbour := int(math.Pow(2, float64(i)))
+		for _, neighbour := range neighbourDistances(r.Topology, size, noKeys) {
 			neighbourIndex := (index + neighbour) % noKeys
 			neighbourKey := r.Keys[neighbourIndex]
 			neighbourNode := r.Nodes[neighbourKey]
@@ -68,6 +82,35 @@ func (r *Registry) GenerateRoutingTables(size int) {
 	r.RTableSize = size
 }
 
+// How far ahead on the ring of noKeys nodes the neighbours of a node are, the successor first
+func neighbourDistances(topology string, size int, noKeys int) []int {
+	distances := []int{}
+	switch topology {
+	case RingTopology:
+		for i := range size {
+			distances = append(distances, i+1)
+		}
+	case RandomTopology:
+		distances = append(distances, 1)
+		for _, distance := range random.Perm(noKeys - 2)[:size-1] {
+			distances = append(distances, distance+2)
+		}
+	default:
+		for i := range size {
+			distances = append(distances, int(math.Pow(2, float64(i))))
+		}
+	}
+	return distances
+}
+
+// The largest routing table the topology can fill with distinct neighbours
+func maxRoutingTableSize(topology string, noKeys int) int {
+	if topology == ChordTopology {
+		return int(math.Floor(math.Log2(float64(noKeys))))
+	}
+	return noKeys - 1
+}
+
 func (r *Registry) AddressExists(address string) bool {
 	for _, node := range r.Nodes {
 		if address == node.Address {
@@ -77,8 +120,17 @@ func (r *Registry) AddressExists(address string) bool {
 	return false
 }
 
+func (r *Registry) IdentityExists(identity string) bool {
+	for _, node := range r.Nodes {
+		if identity == node.Identity {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *Registry) generateId() int32 {
-	index := rand.IntN(len(r.IdSpace))
+	index := random.IntN(len(r.IdSpace))
 
 	id := r.IdSpace[index]
 
@@ -101,22 +153,39 @@ func deleteKey(keys []int32, id int32) []int32 {
 	return keys
 }
 
+// Whether the host of the address a node claims is the one its connection comes from.
+// The claimed host may be a name, which matches any of the addresses it resolves to.
 func verifyAddress(clientAddr string, connAddr string) bool {
-	clientIp, _, err := net.SplitHostPort(clientAddr)
+	clientHost, _, err := net.SplitHostPort(clientAddr)
 
 	if err != nil {
 		return false
 	}
 
-	connIp, _, err := net.SplitHostPort(connAddr)
+	connHost, _, err := net.SplitHostPort(connAddr)
 
 	if err != nil {
 		return false
 	}
 
-	if clientIp == connIp {
-		return true
+	connIp := net.ParseIP(connHost)
+	if connIp == nil {
+		return false
 	}
 
+	// Equal treats an IPv4 address and its IPv6 mapped form as the same
+	if clientIp := net.ParseIP(clientHost); clientIp != nil {
+		return clientIp.Equal(connIp)
+	}
+
+	clientIps, err := net.LookupIP(clientHost)
+	if err != nil {
+		return false
+	}
+	for _, clientIp := range clientIps {
+		if clientIp.Equal(connIp) {
+			return true
+		}
+	}
 	return false
 }
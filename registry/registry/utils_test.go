@@ -0,0 +1,30 @@
+package registry
+
+import "testing"
+
+func TestVerifyAddress(t *testing.T) {
+	tests := []struct {
+		registered string
+		connection string
+		valid      bool
+	}{
+		{registered: "127.0.0.1:5000", connection: "127.0.0.1:40000", valid: true},
+		{registered: "127.0.0.2:5000", connection: "127.0.0.1:40000", valid: false},
+		{registered: "[::1]:5000", connection: "[::1]:40000", valid: true},
+		{registered: "[::ffff:127.0.0.1]:5000", connection: "127.0.0.1:40000", valid: true},
+		{registered: "127.0.0.1:5000", connection: "[::ffff:127.0.0.1]:40000", valid: true},
+		{registered: "[::1]:5000", connection: "127.0.0.1:40000", valid: false},
+		{registered: "localhost:5000", connection: "127.0.0.1:40000", valid: true},
+		{registered: "localhost:5000", connection: "10.0.0.1:40000", valid: false},
+		{registered: "no-such-host.invalid:5000", connection: "127.0.0.1:40000", valid: false},
+		{registered: "127.0.0.1", connection: "127.0.0.1:40000", valid: false},
+		{registered: "127.0.0.1:5000", connection: "127.0.0.1", valid: false},
+		{registered: "127.0.0.1:5000", connection: "localhost:40000", valid: false},
+	}
+
+	for _, test := range tests {
+		if valid := verifyAddress(test.registered, test.connection); valid != test.valid {
+			t.Errorf("verifyAddress(%q, %q) = %v, want %v", test.registered, test.connection, valid, test.valid)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package registry
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/lsig/OverlayNetwork/logger"
+)
+
+// The outcome of checking the summaries of a task
+type Verdict int
+
+const (
+	NotVerified Verdict = iota // the task was aborted, so packets were lost on purpose
+	Passed
+	Failed
+)
+
+func (v Verdict) String() string {
+	switch v {
+	case Passed:
+		return "PASS"
+	case Failed:
+		return "FAIL"
+	default:
+		return "NOT VERIFIED"
+	}
+}
+
+// Checks the invariants every task that ran to completion must hold:
+// every node reported, every packet sent arrived with the payload it was sent with,
+// and every relay counted by a node shows up in the hops of a packet that arrived.
+func (r *Registry) verifyRun() Verdict {
+	if r.Aborted {
+		fmt.Println("Verdict | NOT VERIFIED (task aborted)")
+		return NotVerified
+	}
+
+	var sent, received, relayed, forged uint64
+	var totalSent, totalReceived int64
+	var hops uint64
+	for _, s := range r.Summaries {
+		sent += uint64(s.Sent)
+		received += uint64(s.Received)
+		relayed += uint64(s.Relayed)
+		forged += uint64(s.Forged)
+		totalSent += s.TotalSent
+		totalReceived += s.TotalReceived
+		hops += s.Hops
+	}
+
+	incomplete := 0
+	for _, flow := range computeFlows(r.Summaries) {
+		if flow.Unreported || flow.Received != flow.Sent {
+			incomplete++
+		}
+	}
+
+	failures := []string{}
+	if len(r.Summaries) != len(r.Keys) {
+		failures = append(failures, fmt.Sprintf("%d of %d nodes reported", len(r.Summaries), len(r.Keys)))
+	}
+	if sent != received {
+		failures = append(failures, fmt.Sprintf("sent %d packets, received %d", sent, received))
+	}
+	if totalSent != totalReceived {
+		failures = append(failures, fmt.Sprintf("payload sent sums to %d, received to %d", totalSent, totalReceived))
+	}
+	if relayed != hops {
+		failures = append(failures, fmt.Sprintf("relayed %d packets, packets received went through %d relays", relayed, hops))
+	}
+	if incomplete > 0 {
+		failures = append(failures, fmt.Sprintf("%d source/destination pairs incomplete", incomplete))
+	}
+	if forged > 0 {
+		failures = append(failures, fmt.Sprintf("%d forged packets dropped", forged))
+	}
+
+	if received > 0 {
+		fmt.Printf("Hops | %d relays, %.2f links per packet\n", hops, float64(hops)/float64(received)+1)
+	}
+	if len(failures) == 0 {
+		fmt.Println("Verdict | PASS")
+		return Passed
+	}
+	for _, failure := range failures {
+		fmt.Printf("Check failed: %s\n", failure)
+	}
+	fmt.Println("Verdict | FAIL")
+	return Failed
+}
+
+// In batch mode the registry exits once its input ends, after the running task concludes.
+// The exit status is 1 if any task failed verification, so scripts can gate on it.
+func (r *Registry) HandleInputClosed() {
+	if r.StartComplete || r.Experiment != nil {
+		logger.Info("Input ended, exiting once the running task or experiment concludes")
+		r.ExitWhenIdle = true
+		return
+	}
+	r.exitBatch()
+}
+
+func (r *Registry) exitBatch() {
+	if r.FailedRuns > 0 {
+		fmt.Printf("%d of %d tasks failed verification\n", r.FailedRuns, r.Runs-r.LoadedRuns)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
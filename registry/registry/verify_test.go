@@ -0,0 +1,43 @@
+package registry
+
+import "testing"
+
+func TestVerifyRun(t *testing.T) {
+	// node 1 sends 2 packets to node 3 over node 2, node 3 sends 1 straight to node 1
+	complete := func() []Summary {
+		return []Summary{
+			{Id: 1, Sent: 2, Received: 1, TotalSent: 10, TotalReceived: -4, SentTo: map[int32]uint32{3: 2}, ReceivedFrom: map[int32]uint32{3: 1}},
+			{Id: 2, Relayed: 2},
+			{Id: 3, Sent: 1, Received: 2, TotalSent: -4, TotalReceived: 10, Hops: 2, SentTo: map[int32]uint32{1: 1}, ReceivedFrom: map[int32]uint32{1: 2}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		change  func(r *Registry)
+		verdict Verdict
+	}{
+		{name: "complete", change: func(r *Registry) {}, verdict: Passed},
+		{name: "aborted", change: func(r *Registry) { r.Aborted = true; r.Summaries = r.Summaries[:1] }, verdict: NotVerified},
+		{name: "node missing", change: func(r *Registry) { r.Keys = append(r.Keys, 4) }, verdict: Failed},
+		{name: "packet lost", change: func(r *Registry) { r.Summaries[2].Received--; r.Summaries[2].ReceivedFrom[1]-- }, verdict: Failed},
+		{name: "payload changed", change: func(r *Registry) { r.Summaries[2].TotalReceived++ }, verdict: Failed},
+		{name: "relay uncounted", change: func(r *Registry) { r.Summaries[1].Relayed-- }, verdict: Failed},
+		{
+			name: "packets swapped between flows",
+			change: func(r *Registry) {
+				r.Summaries[0].SentTo = map[int32]uint32{2: 1, 3: 1}
+			},
+			verdict: Failed,
+		},
+		{name: "forged packet", change: func(r *Registry) { r.Summaries[0].Forged = 1 }, verdict: Failed},
+	}
+
+	for _, test := range tests {
+		r := &Registry{Keys: []int32{1, 2, 3}, Summaries: complete()}
+		test.change(r)
+		if verdict := r.verifyRun(); verdict != test.verdict {
+			t.Errorf("%s: verifyRun = %s, want %s", test.name, verdict, test.verdict)
+		}
+	}
+}
@@ -1,9 +1,4 @@
 #!/bin/bash
 
-xterm -e "cd registry; go run registry.go" &
-
-sleep 4
-
-for i in {1..10}; do
-  xterm -e "cd messages; go run messages.go localhost:8080" &
-done
+# spins up a registry and 10 nodes, see "A single binary" in the README
+go run ./cmd/overlay cluster -n 10 "$@"
@@ -0,0 +1,128 @@
+package transport
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+)
+
+// In-memory transport, connecting both ends with net.Pipe.
+// Only reaches listeners created through the same Pipe value,
+// which makes it possible to run a whole overlay inside a single process.
+type Pipe struct {
+	lock      sync.Mutex
+	listeners map[string]*pipeListener
+	nextPort  int // next port of a dialing end
+	anyPort   int // next port of a listener on port 0
+}
+
+// Shared by everything in the process that selects the "pipe" transport
+var SharedPipe = NewPipe()
+
+func NewPipe() *Pipe {
+	return &Pipe{listeners: map[string]*pipeListener{}, nextPort: 1, anyPort: firstEphemeralPort}
+}
+
+func (p *Pipe) Name() string {
+	return "pipe"
+}
+
+func (p *Pipe) Listen(address string) (net.Listener, error) {
+	canonical, err := canonicalAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if anyPort(canonical) {
+		for tries := 0; ; tries++ {
+			if tries > lastEphemeralPort-firstEphemeralPort {
+				return nil, fmt.Errorf("listen pipe %s: no free port", address)
+			}
+			candidate := withPort(canonical, p.anyPort)
+			p.anyPort++
+			if p.anyPort > lastEphemeralPort {
+				p.anyPort = firstEphemeralPort
+			}
+			if _, ok := p.listeners[candidate]; !ok {
+				canonical = candidate
+				break
+			}
+		}
+	}
+
+	if _, ok := p.listeners[canonical]; ok {
+		return nil, fmt.Errorf("listen pipe %s: address already in use", address)
+	}
+
+	listener := &pipeListener{
+		pipe:   p,
+		addr:   addr{network: "pipe", address: canonical},
+		conns:  make(chan net.Conn),
+		closed: make(chan struct{}),
+	}
+	p.listeners[canonical] = listener
+	return listener, nil
+}
+
+func (p *Pipe) Dial(address string) (net.Conn, error) {
+	canonical, err := canonicalAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	p.lock.Lock()
+	listener, ok := p.listeners[canonical]
+	// every dialing end gets its own made up port, so that peers can be told apart in the logs
+	local := addr{network: "pipe", address: net.JoinHostPort("127.0.0.1", strconv.Itoa(p.nextPort))}
+	p.nextPort++
+	p.lock.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("dial pipe %s: connection refused", address)
+	}
+
+	client, server := net.Pipe()
+	select {
+	case listener.conns <- &conn{Conn: server, local: listener.addr, remote: local}:
+		return &conn{Conn: client, local: local, remote: listener.addr}, nil
+	case <-listener.closed:
+		client.Close()
+		server.Close()
+		return nil, fmt.Errorf("dial pipe %s: connection refused", address)
+	}
+}
+
+type pipeListener struct {
+	pipe      *Pipe
+	addr      addr
+	conns     chan net.Conn
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *pipeListener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.closed)
+		l.pipe.lock.Lock()
+		delete(l.pipe.listeners, l.addr.address)
+		l.pipe.lock.Unlock()
+	})
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr {
+	return l.addr
+}
@@ -0,0 +1,33 @@
+package transport
+
+import "net"
+
+// Plain TCP, the transport the overlay has always used
+type TCP struct {
+	Source net.IP // address connections are dialed from, any if nil
+}
+
+func (t *TCP) Name() string {
+	return "tcp"
+}
+
+func (t *TCP) Listen(address string) (net.Listener, error) {
+	return net.Listen("tcp", address)
+}
+
+func (t *TCP) Dial(address string) (net.Conn, error) {
+	tcpServer, err := net.ResolveTCPAddr("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	var local *net.TCPAddr
+	if t.Source != nil {
+		local = &net.TCPAddr{IP: t.Source}
+	}
+	return net.DialTCP("tcp", local, tcpServer)
+}
+
+func (t *TCP) SetSource(ip net.IP) error {
+	t.Source = ip
+	return nil
+}
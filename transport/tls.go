@@ -0,0 +1,104 @@
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+// Mutual TLS on top of another transport.
+// Both ends present a certificate signed by the local CA, see GenerateCA and GenerateCertificate.
+type TLS struct {
+	Inner  Transport
+	Config *tls.Config
+}
+
+// Wraps inner in mutual TLS, using the CA and the certificate/key pair in the given PEM files
+func WithTLS(inner Transport, caFile string, certFile string, keyFile string) (*TLS, error) {
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading CA certificate: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in %s", caFile)
+	}
+
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error loading certificate: %w", err)
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		RootCAs:      pool,
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	return &TLS{Inner: inner, Config: config}, nil
+}
+
+func (t *TLS) Name() string {
+	return t.Inner.Name() + "+tls"
+}
+
+func (t *TLS) Listen(address string) (net.Listener, error) {
+	listener, err := t.Inner.Listen(address)
+	if err != nil {
+		return nil, err
+	}
+	return tls.NewListener(listener, t.Config), nil
+}
+
+func (t *TLS) SetSource(ip net.IP) error {
+	return SetSource(t.Inner, ip)
+}
+
+// How long the other end of a dialed connection has to complete the tls handshake
+var HandshakeTimeout = 10 * time.Second
+
+func (t *TLS) Dial(address string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := t.Inner.Dial(address)
+	if err != nil {
+		return nil, err
+	}
+
+	// the certificate of the other end must be issued for the host we think we're talking to
+	config := t.Config.Clone()
+	config.ServerName = host
+
+	tlsConn := tls.Client(c, config)
+	// handshake right away, so a bad certificate fails the dial instead of the first message.
+	// The deadline keeps a listener that never answers from holding up the dial for good
+	c.SetDeadline(time.Now().Add(HandshakeTimeout))
+	if err := tlsConn.Handshake(); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("tls handshake with %s failed: %w", address, err)
+	}
+	c.SetDeadline(time.Time{})
+	return tlsConn, nil
+}
+
+// Returns the verified certificate presented by the other end of a TLS connection,
+// or nil if the connection doesn't use TLS
+func PeerCertificate(conn net.Conn) *x509.Certificate {
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		return nil
+	}
+	state := tlsConn.ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		return nil
+	}
+	return state.PeerCertificates[0]
+}
@@ -0,0 +1,90 @@
+package transport
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTLSRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	if err := GenerateCA(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := GenerateCertificate(dir, "node", []string{"127.0.0.1"}); err != nil {
+		t.Fatal(err)
+	}
+	tr, err := WithTLS(NewPipe(), filepath.Join(dir, "ca.pem"), filepath.Join(dir, "node.pem"), filepath.Join(dir, "node-key.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		listen string
+		valid  bool
+	}{
+		{listen: "127.0.0.1:5001", valid: true},
+		{listen: "localhost:5002", valid: true},
+		// the certificate isn't issued for this host
+		{listen: "127.0.0.2:5003"},
+	}
+
+	for _, test := range tests {
+		listener, err := tr.Listen(test.listen)
+		if err != nil {
+			t.Errorf("listen on %s failed: %v", test.listen, err)
+			continue
+		}
+		err = roundTrip(t, tr, listener, listener.Addr().String())
+		if test.valid && err != nil {
+			t.Errorf("round trip to %s failed: %v", test.listen, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("round trip to %s succeeded, want a failed handshake", test.listen)
+		}
+		listener.Close()
+	}
+}
+
+func TestTLSHandshakeTimeout(t *testing.T) {
+	dir := t.TempDir()
+	if err := GenerateCA(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := GenerateCertificate(dir, "node", []string{"127.0.0.1"}); err != nil {
+		t.Fatal(err)
+	}
+	pipe := NewPipe()
+	tr, err := WithTLS(pipe, filepath.Join(dir, "ca.pem"), filepath.Join(dir, "node.pem"), filepath.Join(dir, "node-key.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// accepts connections, but never answers the handshake
+	listener, err := pipe.Listen("127.0.0.1:5001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+
+	timeout := HandshakeTimeout
+	HandshakeTimeout = 100 * time.Millisecond
+	defer func() { HandshakeTimeout = timeout }()
+
+	started := time.Now()
+	if conn, err := tr.Dial("127.0.0.1:5001"); err == nil {
+		conn.Close()
+		t.Fatal("dial succeeded without a handshake")
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("dial gave up after %s, want about %s", elapsed, HandshakeTimeout)
+	}
+	(<-accepted).Close()
+}
@@ -0,0 +1,144 @@
+package transport
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Transport is how the registry and messaging nodes listen for and reach each other.
+// Addresses are always given as <host>:<port>, whatever the underlying medium is,
+// so the rest of the overlay never has to know which transport is in use.
+// Listening on port 0 picks a free port, which the listener's Addr reports.
+type Transport interface {
+	Listen(address string) (net.Listener, error)
+	Dial(address string) (net.Conn, error)
+	Name() string
+}
+
+// Implemented by transports whose connections can leave from a given local address,
+// so that a host with several addresses is seen by the other end as the one it advertises
+type SourceSetter interface {
+	SetSource(ip net.IP) error
+}
+
+// Makes the connections tr dials leave from ip, if the transport uses IP addressing
+func SetSource(tr Transport, ip net.IP) error {
+	setter, ok := tr.(SourceSetter)
+	if !ok {
+		return fmt.Errorf("the %s transport can't dial from a given address", tr.Name())
+	}
+	return setter.SetSource(ip)
+}
+
+// Whether the addresses of tr are those of the host, which other protocols such as udp can be used on as well
+func UsesIP(tr Transport) bool {
+	switch t := tr.(type) {
+	case *TCP:
+		return true
+	case *TLS:
+		return UsesIP(t.Inner)
+	default:
+		return false
+	}
+}
+
+// Names of the transports that can be selected on the command line
+var Names = []string{"tcp", "unix", "pipe"}
+
+// Returns the transport registered under the given name.
+// "pipe" returns the process wide in-memory transport,
+// so every registry and node created in the same process can reach each other.
+func New(name string) (Transport, error) {
+	switch name {
+	case "tcp":
+		return &TCP{}, nil
+	case "unix":
+		return NewUnix(""), nil
+	case "pipe":
+		return SharedPipe, nil
+	default:
+		return nil, fmt.Errorf("unknown transport %q, must be one of: %s", name, strings.Join(Names, ", "))
+	}
+}
+
+// Returns the named transport, wrapped in mutual TLS when a certificate is given.
+// Either all or none of the CA, certificate and key files must be given.
+func Setup(name string, caFile string, certFile string, keyFile string) (Transport, error) {
+	tr, err := New(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if caFile == "" && certFile == "" && keyFile == "" {
+		return tr, nil
+	}
+	if caFile == "" || certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("tls needs a CA, a certificate and a key")
+	}
+
+	return WithTLS(tr, caFile, certFile, keyFile)
+}
+
+// Transports that don't use IP addressing rewrite "localhost" to the loopback address,
+// so that a listener on localhost:8080 and a dial to 127.0.0.1:8080 end up at the same place.
+func canonicalAddress(address string) (string, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", err
+	}
+	if host == "localhost" || host == "" {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// Ports handed out to listeners on port 0 by transports that don't have an OS to pick them,
+// the range operating systems use for their ephemeral ports
+const (
+	firstEphemeralPort = 49152
+	lastEphemeralPort  = 65535
+)
+
+// Whether the address asks for a port to be picked, as port 0 does for tcp
+func anyPort(address string) bool {
+	_, port, err := net.SplitHostPort(address)
+	return err == nil && port == "0"
+}
+
+// Replaces the port of an address
+func withPort(address string, port int) string {
+	host, _, _ := net.SplitHostPort(address)
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// net.Addr for transports which only emulate <host>:<port> addressing
+type addr struct {
+	network string
+	address string
+}
+
+func (a addr) Network() string {
+	return a.network
+}
+
+func (a addr) String() string {
+	return a.address
+}
+
+// Wraps a connection so that it reports the overlay addresses
+// instead of the addresses of the underlying medium (socket paths, pipes).
+type conn struct {
+	net.Conn
+	local  net.Addr
+	remote net.Addr
+}
+
+func (c *conn) LocalAddr() net.Addr {
+	return c.local
+}
+
+func (c *conn) RemoteAddr() net.Addr {
+	return c.remote
+}
@@ -0,0 +1,104 @@
+package transport
+
+import (
+	"io"
+	"net"
+	"testing"
+)
+
+// Dials the listener through tr at address, and checks that a message makes it there and back.
+// The accepting end answers from its own goroutine, as both ends of a pipe block until the other reads.
+func roundTrip(t *testing.T, tr Transport, listener net.Listener, address string) error {
+	t.Helper()
+
+	served := make(chan error, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			served <- err
+			return
+		}
+		defer conn.Close()
+		buffer := make([]byte, 4)
+		if _, err := io.ReadFull(conn, buffer); err != nil {
+			served <- err
+			return
+		}
+		_, err = conn.Write([]byte("pong"))
+		served <- err
+	}()
+
+	conn, err := tr.Dial(address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if conn.RemoteAddr().String() != listener.Addr().String() {
+		t.Errorf("dialed %s, connected to %s, want %s", address, conn.RemoteAddr(), listener.Addr())
+	}
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		return err
+	}
+	buffer := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buffer); err != nil {
+		return err
+	}
+	if string(buffer) != "pong" {
+		t.Errorf("received %q, want %q", buffer, "pong")
+	}
+	// the accepting end closes first, and TLS lets it say so before the pipe goes away
+	if _, err := io.Copy(io.Discard, conn); err != nil {
+		return err
+	}
+	return <-served
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		transport Transport
+		listen    string
+		// the address of the listener if empty
+		dial string
+	}{
+		{name: "tcp", transport: &TCP{}, listen: "127.0.0.1:0"},
+		{name: "unix", transport: NewUnix(t.TempDir()), listen: "localhost:5001", dial: "127.0.0.1:5001"},
+		{name: "pipe", transport: NewPipe(), listen: "localhost:5001", dial: "127.0.0.1:5001"},
+	}
+
+	for _, test := range tests {
+		listener, err := test.transport.Listen(test.listen)
+		if err != nil {
+			t.Errorf("%s: listen on %s failed: %v", test.name, test.listen, err)
+			continue
+		}
+		address := test.dial
+		if address == "" {
+			address = listener.Addr().String()
+		}
+		if err := roundTrip(t, test.transport, listener, address); err != nil {
+			t.Errorf("%s: round trip to %s failed: %v", test.name, address, err)
+		}
+
+		if _, err := test.transport.Listen(listener.Addr().String()); err == nil {
+			t.Errorf("%s: listening twice on %s succeeded", test.name, listener.Addr())
+		}
+		listener.Close()
+		if conn, err := test.transport.Dial(address); err == nil {
+			conn.Close()
+			t.Errorf("%s: dial to %s succeeded after the listener was closed", test.name, address)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, name := range Names {
+		if tr, err := New(name); err != nil || tr.Name() != name {
+			t.Errorf("New(%q) = %v, %v", name, tr, err)
+		}
+	}
+	if _, err := New("carrier-pigeon"); err == nil {
+		t.Error("New of an unknown transport succeeded")
+	}
+}
@@ -0,0 +1,115 @@
+package transport
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Unix domain sockets, one socket file per overlay address inside Dir.
+// Both ends of a unix socket are on the same machine,
+// so peers are reported as coming from the loopback address.
+type Unix struct {
+	Dir string
+}
+
+// Creates a unix transport keeping its sockets in dir,
+// or in a directory below the OS temp directory if dir is empty
+func NewUnix(dir string) *Unix {
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "overlay-sockets")
+	}
+	return &Unix{Dir: dir}
+}
+
+func (u *Unix) Name() string {
+	return "unix"
+}
+
+// Maps an overlay address to the path of its socket file
+func (u *Unix) path(address string) (string, error) {
+	canonical, err := canonicalAddress(address)
+	if err != nil {
+		return "", err
+	}
+	replacer := strings.NewReplacer(":", "_", "[", "", "]", "")
+	return filepath.Join(u.Dir, replacer.Replace(canonical)+".sock"), nil
+}
+
+func (u *Unix) Listen(address string) (net.Listener, error) {
+	if _, err := u.path(address); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(u.Dir, 0o700); err != nil {
+		return nil, err
+	}
+	if anyPort(address) {
+		return u.listenAnyPort(address)
+	}
+
+	path, _ := u.path(address)
+
+	// a socket file left behind by a crashed process would make listening fail,
+	// so remove it unless something is still accepting connections on it
+	if c, err := net.Dial("unix", path); err == nil {
+		c.Close()
+		return nil, fmt.Errorf("listen unix %s: address already in use", address)
+	}
+	os.Remove(path)
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	local, _ := canonicalAddress(address)
+	return &unixListener{Listener: listener, addr: addr{network: "unix", address: local}}, nil
+}
+
+// Listens on a port no socket file exists for yet. Creating the socket file fails if it exists,
+// so processes picking the same port at once can't both get it.
+func (u *Unix) listenAnyPort(address string) (net.Listener, error) {
+	for range lastEphemeralPort - firstEphemeralPort {
+		port := firstEphemeralPort + rand.IntN(lastEphemeralPort-firstEphemeralPort+1)
+		local, _ := canonicalAddress(withPort(address, port))
+		path, _ := u.path(local)
+		listener, err := net.Listen("unix", path)
+		if err != nil {
+			continue
+		}
+		return &unixListener{Listener: listener, addr: addr{network: "unix", address: local}}, nil
+	}
+	return nil, fmt.Errorf("listen unix %s: no free port", address)
+}
+
+func (u *Unix) Dial(address string) (net.Conn, error) {
+	path, err := u.path(address)
+	if err != nil {
+		return nil, err
+	}
+	c, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	remote, _ := canonicalAddress(address)
+	return &conn{Conn: c, local: addr{network: "unix", address: "127.0.0.1:0"}, remote: addr{network: "unix", address: remote}}, nil
+}
+
+type unixListener struct {
+	net.Listener
+	addr addr
+}
+
+func (l *unixListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: c, local: l.addr, remote: addr{network: "unix", address: "127.0.0.1:0"}}, nil
+}
+
+func (l *unixListener) Addr() net.Addr {
+	return l.addr
+}